@@ -2,8 +2,12 @@ module github.com/nycmonkey/confluence-reader
 
 go 1.23.0
 
+require (
+	github.com/JohannesKaufmann/html-to-markdown/v2 v2.4.0
+	golang.org/x/text v0.28.0
+)
+
 require (
 	github.com/JohannesKaufmann/dom v0.2.0 // indirect
-	github.com/JohannesKaufmann/html-to-markdown/v2 v2.4.0 // indirect
 	golang.org/x/net v0.43.0 // indirect
 )
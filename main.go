@@ -2,16 +2,66 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/nycmonkey/confluence-reader/pkg/client"
 	"github.com/nycmonkey/confluence-reader/pkg/clone"
 )
 
 func main() {
+	// Command-line flags take precedence over their environment variables
+	maxRetries := flag.Int("max-retries", envInt("CONFLUENCE_MAX_RETRIES", 4), "retries for throttled or failed requests (env CONFLUENCE_MAX_RETRIES)")
+	rateLimit := flag.Float64("rate-limit", envFloat("CONFLUENCE_RATE_LIMIT", 0), "maximum API requests per second across all workers, 0 for unlimited (env CONFLUENCE_RATE_LIMIT)")
+	rateBurst := flag.Int("rate-burst", envInt("CONFLUENCE_RATE_BURST", 1), "requests allowed in a burst above the rate limit (env CONFLUENCE_RATE_BURST)")
+	spaceWorkers := flag.Int("space-workers", envInt("CONFLUENCE_SPACE_WORKERS", 1), "spaces cloned at once (env CONFLUENCE_SPACE_WORKERS)")
+	pageWorkers := flag.Int("page-workers", envInt("CONFLUENCE_PAGE_WORKERS", 5), "pages and blog posts cloned at once, across all spaces (env CONFLUENCE_PAGE_WORKERS)")
+	attachmentWorkers := flag.Int("attachment-workers", envInt("CONFLUENCE_ATTACHMENT_WORKERS", 5), "attachments downloaded at once, across all pages (env CONFLUENCE_ATTACHMENT_WORKERS)")
+	maxInFlight := flag.Int("max-in-flight", envInt("CONFLUENCE_MAX_IN_FLIGHT", 0), "HTTP requests open at once, 0 for unlimited (env CONFLUENCE_MAX_IN_FLIGHT)")
+	pageSize := flag.Int("page-size", envInt("CONFLUENCE_PAGE_SIZE", 100), "results requested per list API call, up to 250 (env CONFLUENCE_PAGE_SIZE)")
+	comments := flag.Bool("comments", envBool("CONFLUENCE_EXPORT_COMMENTS", false), "save footer and inline comments with their replies, one more request per page and blog post (env CONFLUENCE_EXPORT_COMMENTS)")
+	versions := flag.String("versions", envString("CONFLUENCE_VERSION_HISTORY", "0"), `historical versions to save per page and blog post: a number for the last N, or "all" (env CONFLUENCE_VERSION_HISTORY)`)
+	layout := flag.String("layout", envString("CONFLUENCE_LAYOUT", "flat"), `page directory layout: "flat" or "nested" under parent pages (env CONFLUENCE_LAYOUT)`)
+	incremental := flag.Bool("incremental", envBool("CONFLUENCE_INCREMENTAL", false), "only fetch pages and attachments that changed since the last run (env CONFLUENCE_INCREMENTAL)")
+	refreshAnnotations := flag.Bool("refresh-annotations", envBool("CONFLUENCE_REFRESH_ANNOTATIONS", false), "with -incremental, re-check the labels and comments of unchanged pages and blog posts, costing requests for each one (env CONFLUENCE_REFRESH_ANNOTATIONS)")
+	deletions := flag.String("deletions", envString("CONFLUENCE_DELETIONS", "report"), `what to do with content deleted upstream: "report", "remove" or "tombstone" (env CONFLUENCE_DELETIONS)`)
+	resume := flag.Bool("resume", envBool("CONFLUENCE_RESUME", false), "continue an interrupted run, skipping finished pages and attachments and retrying failed ones (env CONFLUENCE_RESUME)")
+	includeSpaces := flag.String("include-spaces", envString("CONFLUENCE_INCLUDE_SPACES", ""), "comma-separated space keys or globs to clone, e.g. DOC,ENG-* (env CONFLUENCE_INCLUDE_SPACES)")
+	excludeSpaces := flag.String("exclude-spaces", envString("CONFLUENCE_EXCLUDE_SPACES", ""), "comma-separated space keys or globs to skip (env CONFLUENCE_EXCLUDE_SPACES)")
+	spaceTypes := flag.String("space-types", envString("CONFLUENCE_SPACE_TYPES", ""), "comma-separated space types to clone, e.g. global (env CONFLUENCE_SPACE_TYPES)")
+	includeTitle := flag.String("include-title", envString("CONFLUENCE_INCLUDE_TITLE", ""), "only clone pages and blog posts whose title matches this regular expression (env CONFLUENCE_INCLUDE_TITLE)")
+	excludeTitle := flag.String("exclude-title", envString("CONFLUENCE_EXCLUDE_TITLE", ""), "skip pages and blog posts whose title matches this regular expression (env CONFLUENCE_EXCLUDE_TITLE)")
+	includeLabels := flag.String("include-labels", envString("CONFLUENCE_INCLUDE_LABELS", ""), "only clone pages and blog posts with one of these comma-separated labels (env CONFLUENCE_INCLUDE_LABELS)")
+	excludeLabels := flag.String("exclude-labels", envString("CONFLUENCE_EXCLUDE_LABELS", ""), "skip pages and blog posts with any of these comma-separated labels (env CONFLUENCE_EXCLUDE_LABELS)")
+	subtree := flag.String("subtree", envString("CONFLUENCE_SUBTREE", ""), "comma-separated page IDs; only clone these pages and the pages below them (env CONFLUENCE_SUBTREE)")
+	modifiedAfter := flag.String("modified-after", envString("CONFLUENCE_MODIFIED_AFTER", ""), "only clone pages and blog posts modified after this date, e.g. 2025-01-31 (env CONFLUENCE_MODIFIED_AFTER)")
+	maxAttachmentSize := flag.String("max-attachment-size", envString("CONFLUENCE_MAX_ATTACHMENT_SIZE", ""), "skip attachments larger than this, e.g. 500MB (env CONFLUENCE_MAX_ATTACHMENT_SIZE)")
+	includeMediaTypes := flag.String("include-media-types", envString("CONFLUENCE_INCLUDE_MEDIA_TYPES", ""), "comma-separated attachment media types or globs to download, e.g. image/*,application/pdf (env CONFLUENCE_INCLUDE_MEDIA_TYPES)")
+	excludeMediaTypes := flag.String("exclude-media-types", envString("CONFLUENCE_EXCLUDE_MEDIA_TYPES", ""), "comma-separated attachment media types or globs to skip, e.g. video/* (env CONFLUENCE_EXCLUDE_MEDIA_TYPES)")
+	includeExtensions := flag.String("include-extensions", envString("CONFLUENCE_INCLUDE_EXTENSIONS", ""), "comma-separated attachment file extensions to download, e.g. pdf,png (env CONFLUENCE_INCLUDE_EXTENSIONS)")
+	excludeExtensions := flag.String("exclude-extensions", envString("CONFLUENCE_EXCLUDE_EXTENSIONS", ""), "comma-separated attachment file extensions to skip, e.g. iso,mov (env CONFLUENCE_EXCLUDE_EXTENSIONS)")
+	attachmentMetadataOnly := flag.Bool("attachment-metadata-only", envBool("CONFLUENCE_ATTACHMENT_METADATA_ONLY", false), "save attachment metadata without downloading the files (env CONFLUENCE_ATTACHMENT_METADATA_ONLY)")
+	blobStore := flag.String("blob-store", envString("CONFLUENCE_BLOB_STORE", "off"), `keep each distinct attachment once under blobs/: "off", "hardlink" or "reference" (env CONFLUENCE_BLOB_STORE)`)
+	includePersonal := flag.Bool("include-personal", envBool("CONFLUENCE_INCLUDE_PERSONAL", false), "clone personal spaces (env CONFLUENCE_INCLUDE_PERSONAL)")
+	includeArchivedSpaces := flag.Bool("include-archived-spaces", envBool("CONFLUENCE_INCLUDE_ARCHIVED_SPACES", false), "clone archived spaces (env CONFLUENCE_INCLUDE_ARCHIVED_SPACES)")
+	includeArchivedPages := flag.Bool("include-archived-pages", envBool("CONFLUENCE_INCLUDE_ARCHIVED_PAGES", false), "clone archived pages and blog posts (env CONFLUENCE_INCLUDE_ARCHIVED_PAGES)")
+	contentStatus := flag.String("content-status", envString("CONFLUENCE_CONTENT_STATUS", ""), "comma-separated page statuses to fetch, e.g. current,archived,draft,trashed (env CONFLUENCE_CONTENT_STATUS)")
+	sampleStrategy := flag.String("sample-strategy", envString("CONFLUENCE_SAMPLE_STRATEGY", "random"), `how sampled pages are picked: "random", "recent", "largest", "oldest", "stratified-per-space" or "stratified-sections" (env CONFLUENCE_SAMPLE_STRATEGY)`)
+	sampleSeed := flag.Int64("sample-seed", envInt64("CONFLUENCE_SAMPLE_SEED", 0), "seed for random samples, so a run picks the same sample again; 0 picks one (env CONFLUENCE_SAMPLE_SEED)")
+	sampleReplay := flag.String("sample-replay", envString("CONFLUENCE_SAMPLE_REPLAY", ""), "clone exactly the content recorded in this sample-manifest.json (env CONFLUENCE_SAMPLE_REPLAY)")
+	dryRun := flag.Bool("dry-run", envBool("CONFLUENCE_DRY_RUN", false), "list what would be cloned and estimate API calls and disk usage, writing only plan.json (env CONFLUENCE_DRY_RUN)")
+	timeout := flag.Duration("timeout", envDuration("CONFLUENCE_TIMEOUT", 0), "abort the clone after this long, e.g. 2h; 0 for no limit (env CONFLUENCE_TIMEOUT)")
+	flag.Parse()
+
 	fmt.Println("Confluence Content Cloner")
 	fmt.Println("========================")
 	fmt.Println()
@@ -22,17 +72,73 @@ func main() {
 	apiToken := os.Getenv("CONFLUENCE_API_TOKEN")
 	outputDir := os.Getenv("CONFLUENCE_OUTPUT_DIR")
 	exportMarkdown := os.Getenv("CONFLUENCE_EXPORT_MARKDOWN")
-	sampleSpacesStr := os.Getenv("CONFLUENCE_SAMPLE_SPACES")
-	samplePagesStr := os.Getenv("CONFLUENCE_SAMPLE_PAGES")
 
 	// Parse sampling values
-	sampleSpaces, err := strconv.Atoi(sampleSpacesStr)
+	sampleSpaces := envInt("CONFLUENCE_SAMPLE_SPACES", 0)
+	samplePages := envInt("CONFLUENCE_SAMPLE_PAGES", 0)
+
+	// Parse sampling strategy
+	strategy, err := clone.ParseSampleStrategy(*sampleStrategy)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Parse output layout
+	pageLayout, err := clone.ParseLayout(*layout)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Parse deletion handling
+	deletionPolicy, err := clone.ParseDeletionPolicy(*deletions)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Parse content filters
+	filters, err := parseFilters(*includeSpaces, *excludeSpaces, *spaceTypes, *includeTitle, *excludeTitle,
+		*includeLabels, *excludeLabels, *subtree, *modifiedAfter)
 	if err != nil {
-		sampleSpaces = 0
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Parse attachment filters
+	attachmentFilters := clone.AttachmentFilters{
+		IncludeMediaTypes: splitList(*includeMediaTypes),
+		ExcludeMediaTypes: splitList(*excludeMediaTypes),
+		IncludeExtensions: splitList(*includeExtensions),
+		ExcludeExtensions: splitList(*excludeExtensions),
+		MetadataOnly:      *attachmentMetadataOnly,
 	}
-	samplePages, err := strconv.Atoi(samplePagesStr)
+	if *maxAttachmentSize != "" {
+		if attachmentFilters.MaxSize, err = parseSize(*maxAttachmentSize); err != nil {
+			fmt.Printf("Error: invalid max-attachment-size: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Parse version history export
+	versionHistory, err := parseVersions(*versions)
 	if err != nil {
-		samplePages = 0
+		fmt.Printf("Error: invalid versions: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Parse attachment blob store mode
+	blobStoreMode, err := clone.ParseBlobStore(*blobStore)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Parse retry configuration
+	retryPolicy := client.DefaultRetryPolicy()
+	if *maxRetries >= 0 {
+		retryPolicy.MaxAttempts = *maxRetries + 1
 	}
 
 	scanner := bufio.NewScanner(os.Stdin)
@@ -98,21 +204,116 @@ func main() {
 
 	// Create client
 	c := client.NewClient(domain, email, apiToken)
+	c.SetRetryPolicy(retryPolicy)
+	c.SetPageSize(*pageSize)
+	if *rateLimit > 0 {
+		fmt.Printf("Rate limit: %.2f requests/second (burst %d)\n", *rateLimit, *rateBurst)
+		c.SetRateLimit(*rateLimit, *rateBurst)
+	}
+	if *maxInFlight > 0 {
+		fmt.Printf("Max in-flight requests: %d\n", *maxInFlight)
+		c.SetMaxInFlight(*maxInFlight)
+	}
 
 	// Create cloner
 	cloner := clone.NewCloner(c, outputDir, sampleSpaces, samplePages)
 
+	// Size the space, page and attachment worker pools
+	cloner.SpaceWorkers = *spaceWorkers
+	cloner.PageWorkers = *pageWorkers
+	cloner.AttachmentWorkers = *attachmentWorkers
+
+	// Configure how samples are picked, or replay an earlier one
+	cloner.SampleStrategy = strategy
+	cloner.SampleSeed = *sampleSeed
+	cloner.SampleReplay = *sampleReplay
+
+	// Comments cost a request per page and blog post, so they're opt-in
+	cloner.ExportComments = *comments
+
+	// Configure version history export
+	cloner.VersionHistory = versionHistory
+	if cloner.VersionHistory != 0 {
+		fmt.Printf("Version history export enabled: %s\n", *versions)
+	}
+
+	// Configure page directory layout
+	cloner.Layout = pageLayout
+	if pageLayout != clone.LayoutFlat {
+		fmt.Printf("Page layout: %s\n", pageLayout)
+	}
+
+	// Skip content already synced by an earlier run
+	cloner.Incremental = *incremental
+	cloner.RefreshAnnotations = *refreshAnnotations
+	if cloner.Incremental {
+		fmt.Println("Incremental sync enabled")
+	}
+
+	// Restrict what is cloned
+	cloner.Filters = filters
+
+	// Restrict which attachments are downloaded
+	cloner.AttachmentFilters = attachmentFilters
+
+	// Deduplicate attachments through the blob store
+	cloner.BlobStore = blobStoreMode
+	if blobStoreMode != clone.BlobStoreOff {
+		fmt.Printf("Attachment blob store: %s\n", blobStoreMode)
+	}
+
+	// Decide which personal, archived, draft and trashed content to clone
+	cloner.IncludePersonalSpaces = *includePersonal
+	cloner.IncludeArchivedSpaces = *includeArchivedSpaces
+	cloner.IncludeArchivedPages = *includeArchivedPages
+	cloner.ContentStatuses = splitList(*contentStatus)
+
+	// Pick up where an interrupted run left off
+	cloner.Resume = *resume
+
+	// Configure handling of content deleted upstream
+	cloner.Deletions = deletionPolicy
+	if deletionPolicy != clone.DeletionReport {
+		fmt.Printf("Deleted content: %s\n", deletionPolicy)
+	}
+
 	// Enable markdown export if requested
 	if exportMarkdown == "true" {
 		cloner.EnableMarkdownExport(domain)
 	}
 
 	// Start cloning
-	fmt.Println("Starting clone process...")
+	if *dryRun {
+		fmt.Println("Starting dry run...")
+	} else {
+		fmt.Println("Starting clone process...")
+	}
 	fmt.Println()
 
-	if err := cloner.Clone(); err != nil {
-		fmt.Printf("Error during clone: %v\n", err)
+	// Ctrl-C, SIGTERM or the timeout cancel in-flight requests and page workers
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	if *dryRun {
+		if _, err := cloner.PlanContext(ctx); err != nil {
+			fmt.Printf("Error during dry run: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Dry run completed; no content was written.")
+		return
+	}
+
+	if err := cloner.CloneContext(ctx); err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			fmt.Printf("\nClone aborted: %v\n", err)
+		} else {
+			fmt.Printf("Error during clone: %v\n", err)
+		}
 		os.Exit(1)
 	}
 
@@ -120,3 +321,139 @@ func main() {
 	fmt.Println("Clone completed successfully!")
 	fmt.Printf("Content saved to: %s\n", outputDir)
 }
+
+// parseFilters builds the content filters from their flag values
+func parseFilters(includeSpaces, excludeSpaces, spaceTypes, includeTitle, excludeTitle,
+	includeLabels, excludeLabels, subtree, modifiedAfter string) (clone.Filters, error) {
+	filters := clone.Filters{
+		IncludeSpaces: splitList(includeSpaces),
+		ExcludeSpaces: splitList(excludeSpaces),
+		SpaceTypes:    splitList(spaceTypes),
+		IncludeLabels: splitList(includeLabels),
+		ExcludeLabels: splitList(excludeLabels),
+		SubtreeRoots:  splitList(subtree),
+	}
+
+	var err error
+	if includeTitle != "" {
+		if filters.IncludeTitle, err = regexp.Compile(includeTitle); err != nil {
+			return filters, fmt.Errorf("invalid include-title pattern: %w", err)
+		}
+	}
+	if excludeTitle != "" {
+		if filters.ExcludeTitle, err = regexp.Compile(excludeTitle); err != nil {
+			return filters, fmt.Errorf("invalid exclude-title pattern: %w", err)
+		}
+	}
+	if modifiedAfter != "" {
+		if filters.ModifiedAfter, err = parseDate(modifiedAfter); err != nil {
+			return filters, fmt.Errorf("invalid modified-after date: %w", err)
+		}
+	}
+	return filters, nil
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseDate parses a date (2006-01-02) or an RFC 3339 timestamp
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// parseSize parses a byte count with an optional binary unit, e.g. 1048576, 512KB or 1.5GB
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		scale  float64
+	}{
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+
+	number, scale := strings.ToUpper(strings.TrimSpace(s)), 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(number, unit.suffix) {
+			number, scale = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix)), unit.scale
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a size", s)
+	}
+	return int64(n * scale), nil
+}
+
+// parseVersions parses how many historical versions to save: "all", or a
+// count where 0 saves none
+func parseVersions(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "all") {
+		return clone.AllVersions, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a number of versions or \"all\"", s)
+	}
+	return n, nil
+}
+
+// envString returns the value of an environment variable, or def if unset
+func envString(name string, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// envBool returns the boolean value of an environment variable, or def if unset
+func envBool(name string, def bool) bool {
+	return envParse(name, def, strconv.ParseBool)
+}
+
+// envInt returns the integer value of an environment variable, or def if unset
+func envInt(name string, def int) int {
+	return envParse(name, def, strconv.Atoi)
+}
+
+// envInt64 returns the 64-bit integer value of an environment variable, or def if unset
+func envInt64(name string, def int64) int64 {
+	return envParse(name, def, func(v string) (int64, error) { return strconv.ParseInt(v, 10, 64) })
+}
+
+// envFloat returns the float value of an environment variable, or def if unset
+func envFloat(name string, def float64) float64 {
+	return envParse(name, def, func(v string) (float64, error) { return strconv.ParseFloat(v, 64) })
+}
+
+// envDuration returns the duration value of an environment variable, or def if unset
+func envDuration(name string, def time.Duration) time.Duration {
+	return envParse(name, def, time.ParseDuration)
+}
+
+// envParse parses an environment variable with parse, returning def if it is
+// unset. An invalid value exits with an error, as an invalid flag would.
+func envParse[T any](name string, def T, parse func(string) (T, error)) T {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return def
+	}
+	v, err := parse(raw)
+	if err != nil {
+		fmt.Printf("Error: invalid %s: %v\n", name, err)
+		os.Exit(1)
+	}
+	return v
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetSpaceBlogPosts(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify path
+		expectedPath := baseAPIPath + "/spaces/123/blogposts"
+		if r.URL.Path != expectedPath {
+			t.Errorf("Expected path %s, got %s", expectedPath, r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"id":"77","title":"Team Update","status":"current","spaceId":"123","createdAt":"2025-03-14T09:30:00.000Z"}]}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	posts, err := client.GetSpaceBlogPosts("123")
+	if err != nil {
+		t.Fatalf("GetSpaceBlogPosts failed: %v", err)
+	}
+
+	if len(posts) != 1 {
+		t.Fatalf("Expected 1 blog post, got %d", len(posts))
+	}
+
+	if posts[0].Title != "Team Update" || posts[0].CreatedAt != "2025-03-14T09:30:00.000Z" {
+		t.Errorf("Unexpected blog post: %+v", posts[0])
+	}
+}
+
+func TestGetBlogPost(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify path
+		expectedPath := baseAPIPath + "/blogposts/77"
+		if r.URL.Path != expectedPath {
+			t.Errorf("Expected path %s, got %s", expectedPath, r.URL.Path)
+		}
+
+		// Verify body-format parameter
+		if r.URL.Query().Get("body-format") != "storage" {
+			t.Error("Expected body-format=storage parameter")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"77","title":"Team Update","version":{"number":3,"createdAt":"2025-03-15T10:00:00.000Z"},"body":{"storage":{"value":"<p>Hello</p>","representation":"storage"}}}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	post, err := client.GetBlogPost("77")
+	if err != nil {
+		t.Fatalf("GetBlogPost failed: %v", err)
+	}
+
+	if post.Body == nil || post.Body.Storage == nil || post.Body.Storage.Value != "<p>Hello</p>" {
+		t.Fatal("Expected blog post body storage to be present")
+	}
+
+	if post.Version == nil || post.Version.Number != 3 {
+		t.Errorf("Expected version 3, got %+v", post.Version)
+	}
+}
+
+func TestGetBlogPostAttachments(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify path
+		expectedPath := baseAPIPath + "/blogposts/77/attachments"
+		if r.URL.Path != expectedPath {
+			t.Errorf("Expected path %s, got %s", expectedPath, r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"id":"att1","title":"banner.png","downloadLink":"/download/attachments/77/banner.png"}]}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	attachments, err := client.GetBlogPostAttachments("77")
+	if err != nil {
+		t.Fatalf("GetBlogPostAttachments failed: %v", err)
+	}
+
+	if len(attachments) != 1 || attachments[0].DownloadURL != "/download/attachments/77/banner.png" {
+		t.Errorf("Unexpected attachments: %+v", attachments)
+	}
+}
@@ -1,9 +1,11 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
 	"net/url"
 	"strings"
@@ -11,35 +13,72 @@ import (
 )
 
 const (
-	baseAPIPath = "/wiki/api/v2"
-	userAgent   = "confluence-reader/1.0"
+	baseAPIPath   = "/wiki/api/v2"
+	baseAPIv1Path = "/wiki/rest/api" // For endpoints with no v2 equivalent
+	userAgent     = "confluence-reader/1.0"
 )
 
 // Client is a Confluence API client
 type Client struct {
-	domain     string
-	scheme     string
-	email      string
-	apiToken   string
-	httpClient *http.Client
+	domain      string
+	scheme      string
+	email       string
+	apiToken    string
+	httpClient  *http.Client
+	retryPolicy RetryPolicy
+	limiter     *rateLimiter
+	inFlight    chan struct{} // Slots for open requests; nil means unlimited
+	pageSize    int
+}
+
+// Option configures a Client created by NewClient
+type Option func(*Client)
+
+// WithHTTPClient sends requests with httpClient instead of the default client,
+// which times out after 30 seconds
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
 }
 
 // NewClient creates a new Confluence API client
-func NewClient(domain, email, apiToken string) *Client {
-	return &Client{
+func NewClient(domain, email, apiToken string, opts ...Option) *Client {
+	c := &Client{
 		domain:   domain,
 		scheme:   "https",
 		email:    email,
 		apiToken: apiToken,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newTransport(),
 		},
+		retryPolicy: DefaultRetryPolicy(),
+		limiter:     newRateLimiter(0, 1),
+		pageSize:    defaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
+}
+
+// newTransport returns the default transport with a bound on how long
+// the server may take to start responding
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ResponseHeaderTimeout = 30 * time.Second
+	return t
 }
 
 // doRequest performs an HTTP request with authentication
-func (c *Client) doRequest(method, path string, queryParams url.Values) ([]byte, error) {
-	u := url.URL{
+func (c *Client) doRequest(ctx context.Context, method, path string, queryParams url.Values) ([]byte, error) {
+	return c.doRequestURL(ctx, method, c.apiURL(path, queryParams))
+}
+
+// apiURL builds the URL of a v2 API path
+func (c *Client) apiURL(path string, queryParams url.Values) *url.URL {
+	u := &url.URL{
 		Scheme: c.scheme,
 		Host:   c.domain,
 		Path:   baseAPIPath + path,
@@ -49,7 +88,12 @@ func (c *Client) doRequest(method, path string, queryParams url.Values) ([]byte,
 		u.RawQuery = queryParams.Encode()
 	}
 
-	req, err := http.NewRequest(method, u.String(), nil)
+	return u
+}
+
+// doRequestURL performs an authenticated HTTP request against a full URL
+func (c *Client) doRequestURL(ctx context.Context, method string, u *url.URL) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -58,7 +102,7 @@ func (c *Client) doRequest(method, path string, queryParams url.Values) ([]byte,
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", userAgent)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(c.httpClient, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -70,7 +114,7 @@ func (c *Client) doRequest(method, path string, queryParams url.Values) ([]byte,
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp, body)
 	}
 
 	return body, nil
@@ -100,58 +144,46 @@ type SpaceResponse struct {
 
 // GetSpaces retrieves all spaces
 func (c *Client) GetSpaces() ([]Space, error) {
-	var allSpaces []Space
-	cursor := ""
-
-	for {
-		params := url.Values{}
-		params.Set("limit", "100")
-		if cursor != "" {
-			params.Set("cursor", cursor)
-		}
-
-		body, err := c.doRequest("GET", "/spaces", params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get spaces: %w", err)
-		}
-
-		var response SpaceResponse
-		if err := json.Unmarshal(body, &response); err != nil {
-			return nil, fmt.Errorf("failed to parse spaces response: %w", err)
-		}
-
-		allSpaces = append(allSpaces, response.Results...)
-
-		if response.Links == nil || response.Links.Next == "" {
-			break
-		}
+	return c.GetSpacesContext(context.Background())
+}
 
-		// Extract cursor from next URL
-		nextURL, err := url.Parse(response.Links.Next)
-		if err != nil {
-			break
-		}
-		cursor = nextURL.Query().Get("cursor")
-		if cursor == "" {
-			break
-		}
+// GetSpacesContext retrieves all spaces, aborting if ctx is cancelled
+func (c *Client) GetSpacesContext(ctx context.Context) ([]Space, error) {
+	spaces, err := Collect(c.Spaces(ctx, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spaces: %w", err)
 	}
+	return spaces, nil
+}
+
+// Spaces returns an iterator over all spaces, fetching them page by page
+func (c *Client) Spaces(ctx context.Context, opts *ListOptions) iter.Seq2[Space, error] {
+	return paginate[Space](ctx, c, "/spaces", opts.values(c))
+}
 
-	return allSpaces, nil
+// Version describes one revision of a page or blog post
+type Version struct {
+	Number    int    `json:"number"`
+	When      string `json:"createdAt"`
+	AuthorID  string `json:"authorId"`
+	Message   string `json:"message"`
+	MinorEdit bool   `json:"minorEdit"`
 }
 
 // Page represents a Confluence page
 type Page struct {
-	ID       string `json:"id"`
-	Status   string `json:"status"`
-	Title    string `json:"title"`
-	SpaceID  string `json:"spaceId"`
-	ParentID string `json:"parentId"`
-	Version  *struct {
-		Number int    `json:"number"`
-		When   string `json:"createdAt"`
-	} `json:"version"`
-	Body *struct {
+	ID         string   `json:"id"`
+	Status     string   `json:"status"`
+	Title      string   `json:"title"`
+	SpaceID    string   `json:"spaceId"`
+	ParentID   string   `json:"parentId"`
+	ParentType string   `json:"parentType"`
+	Position   int      `json:"position"`
+	AuthorID   string   `json:"authorId"`
+	OwnerID    string   `json:"ownerId"`
+	CreatedAt  string   `json:"createdAt"`
+	Version    *Version `json:"version"`
+	Body       *struct {
 		Storage *struct {
 			Value          string `json:"value"`
 			Representation string `json:"representation"`
@@ -169,53 +201,63 @@ type PageResponse struct {
 
 // GetSpacePages retrieves all pages in a space
 func (c *Client) GetSpacePages(spaceID string) ([]Page, error) {
-	var allPages []Page
-	cursor := ""
-
-	for {
-		params := url.Values{}
-		params.Set("limit", "100")
-		if cursor != "" {
-			params.Set("cursor", cursor)
-		}
+	return c.GetSpacePagesContext(context.Background(), spaceID)
+}
 
-		path := fmt.Sprintf("/spaces/%s/pages", spaceID)
-		body, err := c.doRequest("GET", path, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get pages for space %s: %w", spaceID, err)
-		}
+// GetSpacePagesContext retrieves all pages in a space, aborting if ctx is cancelled
+func (c *Client) GetSpacePagesContext(ctx context.Context, spaceID string) ([]Page, error) {
+	pages, err := Collect(c.SpacePages(ctx, spaceID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pages for space %s: %w", spaceID, err)
+	}
+	return pages, nil
+}
 
-		var response PageResponse
-		if err := json.Unmarshal(body, &response); err != nil {
-			return nil, fmt.Errorf("failed to parse pages response: %w", err)
-		}
+// SpacePages returns an iterator over the pages in a space, fetching them page by page
+func (c *Client) SpacePages(ctx context.Context, spaceID string, opts *ListOptions) iter.Seq2[Page, error] {
+	path := fmt.Sprintf("/spaces/%s/pages", spaceID)
+	return paginate[Page](ctx, c, path, opts.values(c))
+}
 
-		allPages = append(allPages, response.Results...)
+// GetPage retrieves a single page with full content
+func (c *Client) GetPage(pageID string) (*Page, error) {
+	return c.GetPageContext(context.Background(), pageID)
+}
 
-		if response.Links == nil || response.Links.Next == "" {
-			break
-		}
+// GetPageContext retrieves a single page with full content, aborting if ctx is cancelled
+func (c *Client) GetPageContext(ctx context.Context, pageID string) (*Page, error) {
+	return c.GetPageWithStatusContext(ctx, pageID, "")
+}
 
-		nextURL, err := url.Parse(response.Links.Next)
-		if err != nil {
-			break
-		}
-		cursor = nextURL.Query().Get("cursor")
-		if cursor == "" {
-			break
-		}
-	}
+// HasBody reports whether the page carries its complete storage-format body,
+// which listings only include when asked for one with ListOptions.BodyFormat
+func (p *Page) HasBody() bool {
+	return p.Body != nil && p.Body.Storage != nil &&
+		completeBody(p.Body.Storage.Value, p.Body.Storage.Representation, p.Version)
+}
 
-	return allPages, nil
+// completeBody reports whether a body from a listing can stand in for fetching
+// the content on its own. Listings can cut a body short, leaving its value
+// empty, or return it in another representation; a blank page costs one
+// extra fetch to tell apart.
+func completeBody(value, representation string, version *Version) bool {
+	if representation != "" && representation != "storage" {
+		return false
+	}
+	return value != "" || version == nil || version.Number == 0
 }
 
-// GetPage retrieves a single page with full content
-func (c *Client) GetPage(pageID string) (*Page, error) {
+// GetPageWithStatusContext retrieves a page in a specific status, such as "draft" or "trashed",
+// which the API doesn't return by default. An empty status behaves like GetPageContext.
+func (c *Client) GetPageWithStatusContext(ctx context.Context, pageID string, status string) (*Page, error) {
 	params := url.Values{}
 	params.Set("body-format", "storage")
+	if status != "" {
+		params.Set("status", status)
+	}
 
 	path := fmt.Sprintf("/pages/%s", pageID)
-	body, err := c.doRequest("GET", path, params)
+	body, err := c.doRequest(ctx, "GET", path, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get page %s: %w", pageID, err)
 	}
@@ -230,11 +272,13 @@ func (c *Client) GetPage(pageID string) (*Page, error) {
 
 // Attachment represents a page attachment
 type Attachment struct {
-	ID        string `json:"id"`
-	Type      string `json:"type"`
-	Title     string `json:"title"`
-	MediaType string `json:"mediaType"`
-	FileSize  int64  `json:"fileSize"`
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Title     string   `json:"title"`
+	Status    string   `json:"status"`
+	MediaType string   `json:"mediaType"`
+	FileSize  int64    `json:"fileSize"`
+	Version   *Version `json:"version"`
 	Download  *struct {
 		URL string `json:"url"`
 	} `json:"-"` // Handled by custom unmarshaler
@@ -287,48 +331,50 @@ type AttachmentResponse struct {
 
 // GetPageAttachments retrieves all attachments for a page
 func (c *Client) GetPageAttachments(pageID string) ([]Attachment, error) {
-	var allAttachments []Attachment
-	cursor := ""
-
-	for {
-		params := url.Values{}
-		params.Set("limit", "100")
-		if cursor != "" {
-			params.Set("cursor", cursor)
-		}
-
-		path := fmt.Sprintf("/pages/%s/attachments", pageID)
-		body, err := c.doRequest("GET", path, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get attachments for page %s: %w", pageID, err)
-		}
+	return c.GetPageAttachmentsContext(context.Background(), pageID)
+}
 
-		var response AttachmentResponse
-		if err := json.Unmarshal(body, &response); err != nil {
-			return nil, fmt.Errorf("failed to parse attachments response: %w", err)
-		}
+// GetPageAttachmentsContext retrieves all attachments for a page, aborting if ctx is cancelled
+func (c *Client) GetPageAttachmentsContext(ctx context.Context, pageID string) ([]Attachment, error) {
+	attachments, err := Collect(c.PageAttachments(ctx, pageID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments for page %s: %w", pageID, err)
+	}
+	return attachments, nil
+}
 
-		allAttachments = append(allAttachments, response.Results...)
+// PageAttachments returns an iterator over a page's attachments, fetching them page by page
+func (c *Client) PageAttachments(ctx context.Context, pageID string, opts *ListOptions) iter.Seq2[Attachment, error] {
+	path := fmt.Sprintf("/pages/%s/attachments", pageID)
+	return paginate[Attachment](ctx, c, path, opts.values(c))
+}
 
-		if response.Links == nil || response.Links.Next == "" {
-			break
-		}
+// DownloadAttachment downloads an attachment into memory.
+// Prefer OpenAttachment for large files.
+func (c *Client) DownloadAttachment(downloadURL string) ([]byte, error) {
+	return c.DownloadAttachmentContext(context.Background(), downloadURL)
+}
 
-		nextURL, err := url.Parse(response.Links.Next)
-		if err != nil {
-			break
-		}
-		cursor = nextURL.Query().Get("cursor")
-		if cursor == "" {
-			break
-		}
+// DownloadAttachmentContext downloads an attachment into memory, aborting if ctx is cancelled
+func (c *Client) DownloadAttachmentContext(ctx context.Context, downloadURL string) ([]byte, error) {
+	body, err := c.OpenAttachmentContext(ctx, downloadURL)
+	if err != nil {
+		return nil, err
 	}
+	defer body.Close()
 
-	return allAttachments, nil
+	return io.ReadAll(body)
 }
 
-// DownloadAttachment downloads an attachment to a writer
-func (c *Client) DownloadAttachment(downloadURL string) ([]byte, error) {
+// OpenAttachment starts downloading an attachment and returns its content as a stream.
+// The caller must close the returned reader.
+func (c *Client) OpenAttachment(downloadURL string) (io.ReadCloser, error) {
+	return c.OpenAttachmentContext(context.Background(), downloadURL)
+}
+
+// OpenAttachmentContext starts downloading an attachment and returns its content as a stream.
+// Cancelling ctx aborts the transfer. The caller must close the returned reader.
+func (c *Client) OpenAttachmentContext(ctx context.Context, downloadURL string) (io.ReadCloser, error) {
 	u, err := url.Parse(downloadURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid download URL: %w", err)
@@ -344,7 +390,7 @@ func (c *Client) DownloadAttachment(downloadURL string) ([]byte, error) {
 		}
 	}
 
-	req, err := http.NewRequest("GET", u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create download request: %w", err)
 	}
@@ -352,15 +398,21 @@ func (c *Client) DownloadAttachment(downloadURL string) ([]byte, error) {
 	req.SetBasicAuth(c.email, c.apiToken)
 	req.Header.Set("User-Agent", userAgent)
 
-	resp, err := c.httpClient.Do(req)
+	// Large files can take far longer than the API timeout to transfer,
+	// so downloads rely on the transport's header timeout and ctx instead
+	streaming := *c.httpClient
+	streaming.Timeout = 0
+
+	resp, err := c.send(&streaming, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute download request: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("download failed with status %d", resp.StatusCode)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("download failed: %w", newAPIError(resp, body))
 	}
 
-	return io.ReadAll(resp.Body)
+	return resp.Body, nil
 }
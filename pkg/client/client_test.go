@@ -1,11 +1,15 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 )
 
 // setupTest creates a test server and a client configured to use it.
@@ -205,6 +209,30 @@ func TestGetPage(t *testing.T) {
 	}
 }
 
+func TestGetPageWithStatus(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify status parameter
+		if r.URL.Query().Get("status") != "trashed" {
+			t.Errorf("Expected status=trashed parameter, got %q", r.URL.Query().Get("status"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"456","title":"Old Page","status":"trashed"}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	page, err := client.GetPageWithStatusContext(context.Background(), "456", "trashed")
+	if err != nil {
+		t.Fatalf("GetPageWithStatusContext failed: %v", err)
+	}
+
+	if page.Status != "trashed" {
+		t.Errorf("Expected trashed page, got status %s", page.Status)
+	}
+}
+
 func TestGetPageAttachments(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify path
@@ -345,3 +373,91 @@ func TestDownloadAttachment(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestCancelledByContext(t *testing.T) {
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetSpacesContext(ctx)
+	if err == nil {
+		t.Fatal("Expected error when context deadline passes")
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected request to abort promptly, took %v", elapsed)
+	}
+}
+
+func TestRetryStopsWhenContextCancelled(t *testing.T) {
+	callCount := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.GetPageContext(ctx, "456"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded while waiting to retry, got %v", err)
+	}
+
+	if callCount != 1 {
+		t.Errorf("Expected 1 attempt before cancellation, got %d", callCount)
+	}
+}
+
+func TestOpenAttachment(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wiki/download/attachments/456/big.bin" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("streamed content"))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	body, err := client.OpenAttachment("/download/attachments/456/big.bin")
+	if err != nil {
+		t.Fatalf("OpenAttachment failed: %v", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("Failed to read stream: %v", err)
+	}
+
+	if string(data) != "streamed content" {
+		t.Errorf("Expected body 'streamed content', got '%s'", string(data))
+	}
+
+	if _, err := client.OpenAttachment("/download/attachments/456/missing.bin"); err == nil {
+		t.Error("Expected error for missing attachment")
+	}
+}
@@ -0,0 +1,280 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+)
+
+// Comment represents a footer or inline comment on a page or blog post
+type Comment struct {
+	ID              string   `json:"id"`
+	Status          string   `json:"status"`
+	Title           string   `json:"title"`
+	PageID          string   `json:"pageId"`
+	BlogPostID      string   `json:"blogPostId"`
+	ParentCommentID string   `json:"parentCommentId"`
+	Version         *Version `json:"version"`
+	Body            *struct {
+		Storage *struct {
+			Value          string `json:"value"`
+			Representation string `json:"representation"`
+		} `json:"storage"`
+	} `json:"body"`
+	// ResolutionStatus is set on inline comments (open, resolved, reopened, dangling)
+	ResolutionStatus string `json:"resolutionStatus"`
+	// Properties is set on inline comments and locates the commented text
+	Properties *InlineProperties `json:"properties"`
+	// Location is "footer" or "inline" on comments from ContentComments,
+	// which lists both kinds together
+	Location string `json:"-"`
+}
+
+// InlineProperties records which part of the page an inline comment is anchored to
+type InlineProperties struct {
+	// MarkerRef matches the ac:ref of the inline-comment-marker in the page body
+	MarkerRef string
+	// OriginalSelection is the text that was highlighted when the comment was made
+	OriginalSelection string
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for InlineProperties
+// The API has returned both camelCase and kebab-case property names, and the
+// v1 API leaves off the inline prefix
+func (p *InlineProperties) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		MarkerRef         string `json:"inlineMarkerRef"`
+		OriginalSelection string `json:"inlineOriginalSelection"`
+		MarkerRefKebab    string `json:"inline-marker-ref"`
+		SelectionKebab    string `json:"inline-original-selection"`
+		MarkerRefV1       string `json:"markerRef"`
+		SelectionV1       string `json:"originalSelection"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	p.MarkerRef = cmp.Or(raw.MarkerRef, raw.MarkerRefKebab, raw.MarkerRefV1)
+	p.OriginalSelection = cmp.Or(raw.OriginalSelection, raw.SelectionKebab, raw.SelectionV1)
+	return nil
+}
+
+// storageBody is the ListOptions used to include comment bodies in listings
+func storageBody(opts *ListOptions) *ListOptions {
+	o := ListOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BodyFormat == "" {
+		o.BodyFormat = "storage"
+	}
+	return &o
+}
+
+// GetPageFooterComments retrieves the top-level footer comments on a page
+func (c *Client) GetPageFooterComments(pageID string) ([]Comment, error) {
+	return c.GetPageFooterCommentsContext(context.Background(), pageID)
+}
+
+// GetPageFooterCommentsContext retrieves the top-level footer comments on a page, aborting if ctx is cancelled
+func (c *Client) GetPageFooterCommentsContext(ctx context.Context, pageID string) ([]Comment, error) {
+	comments, err := Collect(c.PageFooterComments(ctx, pageID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get footer comments for page %s: %w", pageID, err)
+	}
+	return comments, nil
+}
+
+// PageFooterComments returns an iterator over the top-level footer comments on a page.
+// Bodies are included in storage format unless opts requests otherwise.
+func (c *Client) PageFooterComments(ctx context.Context, pageID string, opts *ListOptions) iter.Seq2[Comment, error] {
+	path := fmt.Sprintf("/pages/%s/footer-comments", pageID)
+	return paginate[Comment](ctx, c, path, storageBody(opts).values(c))
+}
+
+// GetPageInlineComments retrieves the top-level inline comments on a page
+func (c *Client) GetPageInlineComments(pageID string) ([]Comment, error) {
+	return c.GetPageInlineCommentsContext(context.Background(), pageID)
+}
+
+// GetPageInlineCommentsContext retrieves the top-level inline comments on a page, aborting if ctx is cancelled
+func (c *Client) GetPageInlineCommentsContext(ctx context.Context, pageID string) ([]Comment, error) {
+	comments, err := Collect(c.PageInlineComments(ctx, pageID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inline comments for page %s: %w", pageID, err)
+	}
+	return comments, nil
+}
+
+// PageInlineComments returns an iterator over the top-level inline comments on a page.
+// Bodies are included in storage format unless opts requests otherwise.
+func (c *Client) PageInlineComments(ctx context.Context, pageID string, opts *ListOptions) iter.Seq2[Comment, error] {
+	path := fmt.Sprintf("/pages/%s/inline-comments", pageID)
+	return paginate[Comment](ctx, c, path, storageBody(opts).values(c))
+}
+
+// GetBlogPostFooterComments retrieves the top-level footer comments on a blog post
+func (c *Client) GetBlogPostFooterComments(blogPostID string) ([]Comment, error) {
+	return c.GetBlogPostFooterCommentsContext(context.Background(), blogPostID)
+}
+
+// GetBlogPostFooterCommentsContext retrieves the top-level footer comments on a blog post, aborting if ctx is cancelled
+func (c *Client) GetBlogPostFooterCommentsContext(ctx context.Context, blogPostID string) ([]Comment, error) {
+	comments, err := Collect(c.BlogPostFooterComments(ctx, blogPostID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get footer comments for blog post %s: %w", blogPostID, err)
+	}
+	return comments, nil
+}
+
+// BlogPostFooterComments returns an iterator over the top-level footer comments on a blog post.
+// Bodies are included in storage format unless opts requests otherwise.
+func (c *Client) BlogPostFooterComments(ctx context.Context, blogPostID string, opts *ListOptions) iter.Seq2[Comment, error] {
+	path := fmt.Sprintf("/blogposts/%s/footer-comments", blogPostID)
+	return paginate[Comment](ctx, c, path, storageBody(opts).values(c))
+}
+
+// GetBlogPostInlineComments retrieves the top-level inline comments on a blog post
+func (c *Client) GetBlogPostInlineComments(blogPostID string) ([]Comment, error) {
+	return c.GetBlogPostInlineCommentsContext(context.Background(), blogPostID)
+}
+
+// GetBlogPostInlineCommentsContext retrieves the top-level inline comments on a blog post, aborting if ctx is cancelled
+func (c *Client) GetBlogPostInlineCommentsContext(ctx context.Context, blogPostID string) ([]Comment, error) {
+	comments, err := Collect(c.BlogPostInlineComments(ctx, blogPostID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inline comments for blog post %s: %w", blogPostID, err)
+	}
+	return comments, nil
+}
+
+// BlogPostInlineComments returns an iterator over the top-level inline comments on a blog post.
+// Bodies are included in storage format unless opts requests otherwise.
+func (c *Client) BlogPostInlineComments(ctx context.Context, blogPostID string, opts *ListOptions) iter.Seq2[Comment, error] {
+	path := fmt.Sprintf("/blogposts/%s/inline-comments", blogPostID)
+	return paginate[Comment](ctx, c, path, storageBody(opts).values(c))
+}
+
+// v1Comment is a comment as the v1 content API returns it
+type v1Comment struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	Title     string `json:"title"`
+	Ancestors []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	} `json:"ancestors"`
+	Version *struct {
+		Number int    `json:"number"`
+		When   string `json:"when"`
+		By     *struct {
+			AccountID string `json:"accountId"`
+		} `json:"by"`
+	} `json:"version"`
+	Body *struct {
+		Storage *struct {
+			Value          string `json:"value"`
+			Representation string `json:"representation"`
+		} `json:"storage"`
+	} `json:"body"`
+	Extensions struct {
+		Location         string            `json:"location"`
+		InlineProperties *InlineProperties `json:"inlineProperties"`
+		Resolution       *struct {
+			Status string `json:"status"`
+		} `json:"resolution"`
+	} `json:"extensions"`
+}
+
+// comment converts a v1 comment to the v2 model, taking its parent from the
+// nearest comment among its ancestors
+func (v v1Comment) comment() Comment {
+	comment := Comment{
+		ID:         v.ID,
+		Status:     v.Status,
+		Title:      v.Title,
+		Body:       v.Body,
+		Properties: v.Extensions.InlineProperties,
+		Location:   v.Extensions.Location,
+	}
+	for _, ancestor := range v.Ancestors {
+		if ancestor.Type == "comment" {
+			comment.ParentCommentID = ancestor.ID
+		}
+	}
+	if v.Version != nil {
+		comment.Version = &Version{Number: v.Version.Number, When: v.Version.When}
+		if v.Version.By != nil {
+			comment.Version.AuthorID = v.Version.By.AccountID
+		}
+	}
+	if v.Extensions.Resolution != nil {
+		comment.ResolutionStatus = v.Extensions.Resolution.Status
+	}
+	return comment
+}
+
+// GetContentComments retrieves every footer and inline comment on a page or
+// blog post, replies included
+func (c *Client) GetContentComments(contentID string) ([]Comment, error) {
+	return c.GetContentCommentsContext(context.Background(), contentID)
+}
+
+// GetContentCommentsContext retrieves every footer and inline comment on a page or blog post, aborting if ctx is cancelled
+func (c *Client) GetContentCommentsContext(ctx context.Context, contentID string) ([]Comment, error) {
+	comments, err := Collect(c.ContentComments(ctx, contentID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments for content %s: %w", contentID, err)
+	}
+	return comments, nil
+}
+
+// ContentComments returns an iterator over every comment on a page or blog
+// post, replies included, each with its Location and ParentCommentID. The v2
+// API only lists top-level comments and needs a request per comment for
+// replies, so this uses the v1 content API, which returns them all at once.
+func (c *Client) ContentComments(ctx context.Context, contentID string, opts *ListOptions) iter.Seq2[Comment, error] {
+	params := opts.values(c)
+	params.Set("depth", "all")
+	params.Set("expand", "body.storage,version,ancestors,extensions.inlineProperties,extensions.resolution")
+	path := fmt.Sprintf("/content/%s/child/comment", contentID)
+	return func(yield func(Comment, error) bool) {
+		for v, err := range paginateV1[v1Comment](ctx, c, path, params) {
+			if !yield(v.comment(), err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// GetFooterCommentReplies retrieves the direct replies to a footer comment
+func (c *Client) GetFooterCommentReplies(commentID string) ([]Comment, error) {
+	return c.GetFooterCommentRepliesContext(context.Background(), commentID)
+}
+
+// GetFooterCommentRepliesContext retrieves the direct replies to a footer comment, aborting if ctx is cancelled
+func (c *Client) GetFooterCommentRepliesContext(ctx context.Context, commentID string) ([]Comment, error) {
+	path := fmt.Sprintf("/footer-comments/%s/children", commentID)
+	replies, err := Collect(paginate[Comment](ctx, c, path, storageBody(nil).values(c)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replies to footer comment %s: %w", commentID, err)
+	}
+	return replies, nil
+}
+
+// GetInlineCommentReplies retrieves the direct replies to an inline comment
+func (c *Client) GetInlineCommentReplies(commentID string) ([]Comment, error) {
+	return c.GetInlineCommentRepliesContext(context.Background(), commentID)
+}
+
+// GetInlineCommentRepliesContext retrieves the direct replies to an inline comment, aborting if ctx is cancelled
+func (c *Client) GetInlineCommentRepliesContext(ctx context.Context, commentID string) ([]Comment, error) {
+	path := fmt.Sprintf("/inline-comments/%s/children", commentID)
+	replies, err := Collect(paginate[Comment](ctx, c, path, storageBody(nil).values(c)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replies to inline comment %s: %w", commentID, err)
+	}
+	return replies, nil
+}
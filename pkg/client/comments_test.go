@@ -0,0 +1,177 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestGetPageFooterComments(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify path
+		expectedPath := baseAPIPath + "/pages/456/footer-comments"
+		if r.URL.Path != expectedPath {
+			t.Errorf("Expected path %s, got %s", expectedPath, r.URL.Path)
+		}
+
+		// Comment bodies are requested with the listing
+		if r.URL.Query().Get("body-format") != "storage" {
+			t.Error("Expected body-format=storage parameter")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"id":"c1","status":"current","pageId":"456","version":{"number":1,"authorId":"acc-1","createdAt":"2025-03-14T09:30:00.000Z"},"body":{"storage":{"value":"<p>Nice</p>","representation":"storage"}}}]}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	comments, err := client.GetPageFooterComments("456")
+	if err != nil {
+		t.Fatalf("GetPageFooterComments failed: %v", err)
+	}
+
+	if len(comments) != 1 {
+		t.Fatalf("Expected 1 comment, got %d", len(comments))
+	}
+
+	c := comments[0]
+	if c.Version == nil || c.Version.AuthorID != "acc-1" {
+		t.Errorf("Expected version author acc-1, got %+v", c.Version)
+	}
+	if c.Body == nil || c.Body.Storage == nil || c.Body.Storage.Value != "<p>Nice</p>" {
+		t.Error("Expected comment body to be present")
+	}
+}
+
+func TestGetPageInlineComments(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify path
+		expectedPath := baseAPIPath + "/pages/456/inline-comments"
+		if r.URL.Path != expectedPath {
+			t.Errorf("Expected path %s, got %s", expectedPath, r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"id":"i1","resolutionStatus":"open","properties":{"inlineMarkerRef":"ref-1","inlineOriginalSelection":"every 90 days"}}]}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	comments, err := client.GetPageInlineComments("456")
+	if err != nil {
+		t.Fatalf("GetPageInlineComments failed: %v", err)
+	}
+
+	if len(comments) != 1 || comments[0].Properties == nil {
+		t.Fatalf("Expected 1 inline comment with properties, got %+v", comments)
+	}
+
+	if comments[0].Properties.OriginalSelection != "every 90 days" || comments[0].Properties.MarkerRef != "ref-1" {
+		t.Errorf("Unexpected inline properties: %+v", comments[0].Properties)
+	}
+
+	if comments[0].ResolutionStatus != "open" {
+		t.Errorf("Expected resolution status open, got %s", comments[0].ResolutionStatus)
+	}
+}
+
+func TestInlinePropertiesKebabCase(t *testing.T) {
+	var props InlineProperties
+	data := []byte(`{"inline-marker-ref":"ref-2","inline-original-selection":"selected text"}`)
+	if err := json.Unmarshal(data, &props); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if props.MarkerRef != "ref-2" || props.OriginalSelection != "selected text" {
+		t.Errorf("Unexpected inline properties: %+v", props)
+	}
+}
+
+func TestGetCommentReplies(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case baseAPIPath + "/footer-comments/c1/children":
+			w.Write([]byte(`{"results":[{"id":"c2","parentCommentId":"c1"}]}`))
+		case baseAPIPath + "/inline-comments/i1/children":
+			w.Write([]byte(`{"results":[{"id":"i2","parentCommentId":"i1"}]}`))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	footerReplies, err := client.GetFooterCommentReplies("c1")
+	if err != nil {
+		t.Fatalf("GetFooterCommentReplies failed: %v", err)
+	}
+	if len(footerReplies) != 1 || footerReplies[0].ParentCommentID != "c1" {
+		t.Errorf("Unexpected footer replies: %+v", footerReplies)
+	}
+
+	inlineReplies, err := client.GetInlineCommentReplies("i1")
+	if err != nil {
+		t.Fatalf("GetInlineCommentReplies failed: %v", err)
+	}
+	if len(inlineReplies) != 1 || inlineReplies[0].ParentCommentID != "i1" {
+		t.Errorf("Unexpected inline replies: %+v", inlineReplies)
+	}
+}
+
+func TestGetContentComments(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want := baseAPIv1Path + "/content/456/child/comment"; r.URL.Path != want {
+			t.Errorf("Expected path %s, got %s", want, r.URL.Path)
+		}
+		if r.URL.Query().Get("depth") != "all" {
+			t.Error("Expected depth=all, so replies come with the listing")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch start := r.URL.Query().Get("start"); start {
+		case "0":
+			w.Write([]byte(`{"results":[
+				{"id":"c1","status":"current","version":{"number":1,"when":"2025-03-14T09:30:00.000Z","by":{"accountId":"acc-1"}},
+					"body":{"storage":{"value":"<p>Nice</p>"}},"extensions":{"location":"footer"}},
+				{"id":"c2","status":"current","ancestors":[{"id":"456","type":"page"},{"id":"c1","type":"comment"}],
+					"version":{"number":1,"by":{"accountId":"acc-2"}},"extensions":{"location":"footer"}}
+			],"start":0,"limit":2,"size":2,"_links":{"next":"/rest/api/content/456/child/comment?start=2"}}`))
+		case "2":
+			w.Write([]byte(`{"results":[
+				{"id":"i1","status":"current","extensions":{"location":"inline",
+					"inlineProperties":{"markerRef":"ref-1","originalSelection":"every 90 days"},"resolution":{"status":"open"}}}
+			],"start":2,"limit":2,"size":1,"_links":{}}`))
+		default:
+			t.Errorf("Unexpected start %q", start)
+		}
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	comments, err := client.GetContentComments("456")
+	if err != nil {
+		t.Fatalf("GetContentComments failed: %v", err)
+	}
+	if len(comments) != 3 {
+		t.Fatalf("Expected 3 comments across both pages, got %+v", comments)
+	}
+
+	c1, c2, i1 := comments[0], comments[1], comments[2]
+	if c1.Location != "footer" || c1.ParentCommentID != "" || c1.Version == nil || c1.Version.AuthorID != "acc-1" ||
+		c1.Version.When != "2025-03-14T09:30:00.000Z" || c1.Body == nil || c1.Body.Storage.Value != "<p>Nice</p>" {
+		t.Errorf("Unexpected footer comment %+v", c1)
+	}
+	if c2.ParentCommentID != "c1" || c2.Version.AuthorID != "acc-2" {
+		t.Errorf("Expected reply c2 to c1 by acc-2, got %+v", c2)
+	}
+	if i1.Location != "inline" || i1.ResolutionStatus != "open" || i1.Properties == nil ||
+		i1.Properties.MarkerRef != "ref-1" || i1.Properties.OriginalSelection != "every 90 days" {
+		t.Errorf("Unexpected inline comment %+v", i1)
+	}
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBody limits how much of an error response is kept for diagnostics
+const maxErrorBody = 4096
+
+// APIError is returned when Confluence responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	// Errors holds the parsed Atlassian error payload, if any
+	Errors []ErrorDetail
+	// Message is the top-level message returned by some endpoints
+	Message string
+	// Body is the raw response body (truncated) when it couldn't be parsed
+	Body string
+	// RetryAfter is the server-requested delay before retrying, if provided
+	RetryAfter time.Duration
+	RateLimit  RateLimitInfo
+}
+
+// ErrorDetail is a single entry in an Atlassian error response
+type ErrorDetail struct {
+	Status int    `json:"status"`
+	Code   string `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// RateLimitInfo holds the X-RateLimit-* headers of a response
+type RateLimitInfo struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	NearLimit bool
+}
+
+// newAPIError builds an APIError from a failed response and its body
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RateLimit:  parseRateLimit(resp.Header),
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.Path = resp.Request.URL.Path
+	}
+	if d, ok := retryAfter(resp.Header, time.Now()); ok {
+		e.RetryAfter = d
+	}
+
+	var payload struct {
+		Errors  []ErrorDetail `json:"errors"`
+		Message string        `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && (len(payload.Errors) > 0 || payload.Message != "") {
+		e.Errors = payload.Errors
+		e.Message = payload.Message
+	} else {
+		if len(body) > maxErrorBody {
+			body = body[:maxErrorBody]
+		}
+		e.Body = strings.TrimSpace(string(body))
+	}
+
+	return e
+}
+
+// parseRateLimit extracts Atlassian's rate-limit headers
+func parseRateLimit(h http.Header) RateLimitInfo {
+	var info RateLimitInfo
+	info.Limit, _ = strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	info.Remaining, _ = strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	info.Reset, _ = time.Parse(time.RFC3339, h.Get("X-RateLimit-Reset"))
+	info.NearLimit = h.Get("X-RateLimit-NearLimit") == "true"
+	return info
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "API request failed with status %d", e.StatusCode)
+	if e.Path != "" {
+		fmt.Fprintf(&sb, " (%s %s)", e.Method, e.Path)
+	}
+
+	var details []string
+	for _, d := range e.Errors {
+		switch {
+		case d.Title != "" && d.Detail != "":
+			details = append(details, d.Title+": "+d.Detail)
+		case d.Title != "":
+			details = append(details, d.Title)
+		case d.Detail != "":
+			details = append(details, d.Detail)
+		}
+	}
+	if e.Message != "" {
+		details = append(details, e.Message)
+	}
+	if len(details) == 0 && e.Body != "" {
+		details = append(details, e.Body)
+	}
+	if len(details) > 0 {
+		sb.WriteString(": ")
+		sb.WriteString(strings.Join(details, "; "))
+	}
+
+	return sb.String()
+}
+
+// IsNotFound reports whether the resource doesn't exist (or isn't visible)
+func (e *APIError) IsNotFound() bool { return e.StatusCode == http.StatusNotFound }
+
+// IsForbidden reports whether the caller lacks permission
+func (e *APIError) IsForbidden() bool { return e.StatusCode == http.StatusForbidden }
+
+// IsUnauthorized reports whether the credentials were rejected
+func (e *APIError) IsUnauthorized() bool { return e.StatusCode == http.StatusUnauthorized }
+
+// IsRateLimited reports whether the request was throttled
+func (e *APIError) IsRateLimited() bool { return e.StatusCode == http.StatusTooManyRequests }
+
+// Retryable reports whether the request may succeed if tried again later
+func (e *APIError) Retryable() bool { return isRetryableStatus(e.StatusCode) }
+
+// IsNotFound reports whether err is an APIError for a missing resource
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsNotFound()
+}
+
+// IsForbidden reports whether err is an APIError for a permission failure
+func IsForbidden(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsForbidden()
+}
+
+// IsUnauthorized reports whether err is an APIError for rejected credentials
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsUnauthorized()
+}
+
+// IsRateLimited reports whether err is an APIError for a throttled request
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.IsRateLimited()
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIErrorParsesAtlassianPayload(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":[{"status":403,"code":"FORBIDDEN","title":"Forbidden","detail":"Not permitted to view space"}]}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	_, err := client.GetSpacePages("123")
+	if err == nil {
+		t.Fatal("Expected error for 403 response")
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *APIError in chain, got %T: %v", err, err)
+	}
+
+	if apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", apiErr.StatusCode)
+	}
+
+	if apiErr.Path != baseAPIPath+"/spaces/123/pages" {
+		t.Errorf("Expected request path to be recorded, got %s", apiErr.Path)
+	}
+
+	if len(apiErr.Errors) != 1 || apiErr.Errors[0].Code != "FORBIDDEN" {
+		t.Errorf("Expected parsed error payload, got %+v", apiErr.Errors)
+	}
+
+	if !IsForbidden(err) || IsNotFound(err) || IsRateLimited(err) {
+		t.Error("Expected only IsForbidden to match")
+	}
+
+	if !strings.Contains(err.Error(), "Not permitted to view space") {
+		t.Errorf("Expected error message to include detail, got %q", err.Error())
+	}
+}
+
+func TestAPIErrorRateLimitHeaders(t *testing.T) {
+	reset := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "12")
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", reset.Format(time.RFC3339))
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte("slow down"))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	_, err := client.GetPage("456")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *APIError in chain, got %v", err)
+	}
+
+	if !apiErr.IsRateLimited() || !apiErr.Retryable() {
+		t.Error("Expected 429 to be rate limited and retryable")
+	}
+
+	if apiErr.RetryAfter != 12*time.Second {
+		t.Errorf("Expected RetryAfter 12s, got %v", apiErr.RetryAfter)
+	}
+
+	if apiErr.RateLimit.Limit != 100 || apiErr.RateLimit.Remaining != 0 || !apiErr.RateLimit.Reset.Equal(reset) {
+		t.Errorf("Unexpected rate limit info: %+v", apiErr.RateLimit)
+	}
+
+	if apiErr.Body != "slow down" {
+		t.Errorf("Expected raw body for non-JSON response, got %q", apiErr.Body)
+	}
+}
+
+func TestDownloadAttachmentNotFound(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	if _, err := client.DownloadAttachment("/download/attachments/1/gone.png"); !IsNotFound(err) {
+		t.Errorf("Expected IsNotFound for missing attachment, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// ChildPage is a direct child of a page, as returned by the children endpoint
+type ChildPage struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	Title         string `json:"title"`
+	SpaceID       string `json:"spaceId"`
+	ChildPosition int    `json:"childPosition"`
+}
+
+// Ancestor is a page or other content above a page in the hierarchy
+type Ancestor struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// Descendant is content anywhere below a page in the hierarchy
+type Descendant struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	Title         string `json:"title"`
+	Type          string `json:"type"`
+	ParentID      string `json:"parentId"`
+	Depth         int    `json:"depth"`
+	ChildPosition int    `json:"childPosition"`
+}
+
+// GetPageChildren retrieves the direct children of a page
+func (c *Client) GetPageChildren(pageID string) ([]ChildPage, error) {
+	return c.GetPageChildrenContext(context.Background(), pageID)
+}
+
+// GetPageChildrenContext retrieves the direct children of a page, aborting if ctx is cancelled
+func (c *Client) GetPageChildrenContext(ctx context.Context, pageID string) ([]ChildPage, error) {
+	children, err := Collect(c.PageChildren(ctx, pageID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get children for page %s: %w", pageID, err)
+	}
+	return children, nil
+}
+
+// PageChildren returns an iterator over the direct children of a page, fetching them page by page
+func (c *Client) PageChildren(ctx context.Context, pageID string, opts *ListOptions) iter.Seq2[ChildPage, error] {
+	path := fmt.Sprintf("/pages/%s/children", pageID)
+	return paginate[ChildPage](ctx, c, path, opts.values(c))
+}
+
+// GetPageAncestors retrieves the ancestors of a page, highest ancestor first
+func (c *Client) GetPageAncestors(pageID string) ([]Ancestor, error) {
+	return c.GetPageAncestorsContext(context.Background(), pageID)
+}
+
+// GetPageAncestorsContext retrieves the ancestors of a page, aborting if ctx is cancelled
+func (c *Client) GetPageAncestorsContext(ctx context.Context, pageID string) ([]Ancestor, error) {
+	ancestors, err := Collect(c.PageAncestors(ctx, pageID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ancestors for page %s: %w", pageID, err)
+	}
+	return ancestors, nil
+}
+
+// PageAncestors returns an iterator over the ancestors of a page, highest ancestor first
+func (c *Client) PageAncestors(ctx context.Context, pageID string, opts *ListOptions) iter.Seq2[Ancestor, error] {
+	path := fmt.Sprintf("/pages/%s/ancestors", pageID)
+	return paginate[Ancestor](ctx, c, path, opts.values(c))
+}
+
+// GetPageDescendants retrieves the content below a page, down to the API's default depth
+func (c *Client) GetPageDescendants(pageID string) ([]Descendant, error) {
+	return c.GetPageDescendantsContext(context.Background(), pageID)
+}
+
+// GetPageDescendantsContext retrieves the content below a page, aborting if ctx is cancelled
+func (c *Client) GetPageDescendantsContext(ctx context.Context, pageID string) ([]Descendant, error) {
+	descendants, err := Collect(c.PageDescendants(ctx, pageID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get descendants for page %s: %w", pageID, err)
+	}
+	return descendants, nil
+}
+
+// PageDescendants returns an iterator over the content below a page, fetching it page by page.
+// Set opts.Depth to control how many levels are returned.
+func (c *Client) PageDescendants(ctx context.Context, pageID string, opts *ListOptions) iter.Seq2[Descendant, error] {
+	path := fmt.Sprintf("/pages/%s/descendants", pageID)
+	return paginate[Descendant](ctx, c, path, opts.values(c))
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestPageHierarchy(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case baseAPIPath + "/pages/456/children":
+			w.Write([]byte(`{"results":[{"id":"457","status":"current","title":"Child","spaceId":"123","childPosition":2}]}`))
+		case baseAPIPath + "/pages/456/ancestors":
+			w.Write([]byte(`{"results":[{"id":"100","type":"page"},{"id":"200","type":"page"}]}`))
+		case baseAPIPath + "/pages/456/descendants":
+			if r.URL.Query().Get("depth") != "3" {
+				t.Errorf("Expected depth=3 parameter, got %q", r.URL.Query().Get("depth"))
+			}
+			w.Write([]byte(`{"results":[
+				{"id":"457","status":"current","title":"Child","type":"page","parentId":"456","depth":1},
+				{"id":"458","status":"current","title":"Grandchild","type":"page","parentId":"457","depth":2}
+			]}`))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	children, err := client.GetPageChildren("456")
+	if err != nil {
+		t.Fatalf("GetPageChildren failed: %v", err)
+	}
+	if len(children) != 1 || children[0].Title != "Child" || children[0].ChildPosition != 2 {
+		t.Errorf("Unexpected children: %+v", children)
+	}
+
+	ancestors, err := client.GetPageAncestors("456")
+	if err != nil {
+		t.Fatalf("GetPageAncestors failed: %v", err)
+	}
+	if len(ancestors) != 2 || ancestors[0].ID != "100" || ancestors[1].Type != "page" {
+		t.Errorf("Unexpected ancestors: %+v", ancestors)
+	}
+
+	var descendants []Descendant
+	for d, err := range client.PageDescendants(context.Background(), "456", &ListOptions{Depth: 3}) {
+		if err != nil {
+			t.Fatalf("PageDescendants failed: %v", err)
+		}
+		descendants = append(descendants, d)
+	}
+	if len(descendants) != 2 || descendants[1].ParentID != "457" || descendants[1].Depth != 2 {
+		t.Errorf("Unexpected descendants: %+v", descendants)
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// SetMaxInFlight caps how many requests the client has open at once across all
+// goroutines. A download counts until its body is closed, so large files hold
+// their slot for the whole transfer. An n of 0 removes the cap.
+func (c *Client) SetMaxInFlight(n int) {
+	if n <= 0 {
+		c.inFlight = nil
+		return
+	}
+	c.inFlight = make(chan struct{}, n)
+}
+
+// acquire waits for an in-flight slot or for ctx to be done. It returns a
+// function that frees the slot, which is safe to call more than once.
+func (c *Client) acquire(ctx context.Context) (func(), error) {
+	slots := c.inFlight
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	var once sync.Once
+	return func() { once.Do(func() { <-slots }) }, nil
+}
+
+// releaseOnClose frees a request's in-flight slot when its body is closed
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
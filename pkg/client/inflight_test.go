@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxInFlight(t *testing.T) {
+	var open, peak atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := open.Add(1)
+		defer open.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1"}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+	client.SetMaxInFlight(2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetPage("1"); err != nil {
+				t.Errorf("GetPage failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got > 2 || got == 0 {
+		t.Errorf("Expected at most 2 requests in flight, saw %d", got)
+	}
+}
+
+func TestMaxInFlightHoldsDownloads(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data"))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+	client.SetMaxInFlight(1)
+
+	body, err := client.OpenAttachment("/download/attachments/1/a.txt")
+	if err != nil {
+		t.Fatalf("OpenAttachment failed: %v", err)
+	}
+
+	// The open download holds the only slot
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetPageContext(ctx, "1"); err == nil {
+		t.Error("Expected a request to wait while a download is open")
+	}
+
+	body.Close()
+	if _, err := client.OpenAttachment("/download/attachments/1/a.txt"); err != nil {
+		t.Errorf("Expected closing the download to free its slot, got %v", err)
+	}
+}
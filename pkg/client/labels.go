@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// Label represents a label attached to a page, blog post or space
+type Label struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+}
+
+// GetPageLabels retrieves the labels on a page
+func (c *Client) GetPageLabels(pageID string) ([]Label, error) {
+	return c.GetPageLabelsContext(context.Background(), pageID)
+}
+
+// GetPageLabelsContext retrieves the labels on a page, aborting if ctx is cancelled
+func (c *Client) GetPageLabelsContext(ctx context.Context, pageID string) ([]Label, error) {
+	path := fmt.Sprintf("/pages/%s/labels", pageID)
+	labels, err := Collect(paginate[Label](ctx, c, path, (*ListOptions)(nil).values(c)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels for page %s: %w", pageID, err)
+	}
+	return labels, nil
+}
+
+// GetBlogPostLabels retrieves the labels on a blog post
+func (c *Client) GetBlogPostLabels(blogPostID string) ([]Label, error) {
+	return c.GetBlogPostLabelsContext(context.Background(), blogPostID)
+}
+
+// GetBlogPostLabelsContext retrieves the labels on a blog post, aborting if ctx is cancelled
+func (c *Client) GetBlogPostLabelsContext(ctx context.Context, blogPostID string) ([]Label, error) {
+	path := fmt.Sprintf("/blogposts/%s/labels", blogPostID)
+	labels, err := Collect(paginate[Label](ctx, c, path, (*ListOptions)(nil).values(c)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels for blog post %s: %w", blogPostID, err)
+	}
+	return labels, nil
+}
+
+// GetSpaceLabels retrieves the labels on a space
+func (c *Client) GetSpaceLabels(spaceID string) ([]Label, error) {
+	return c.GetSpaceLabelsContext(context.Background(), spaceID)
+}
+
+// GetSpaceLabelsContext retrieves the labels on a space, aborting if ctx is cancelled
+func (c *Client) GetSpaceLabelsContext(ctx context.Context, spaceID string) ([]Label, error) {
+	path := fmt.Sprintf("/spaces/%s/labels", spaceID)
+	labels, err := Collect(paginate[Label](ctx, c, path, (*ListOptions)(nil).values(c)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels for space %s: %w", spaceID, err)
+	}
+	return labels, nil
+}
+
+// LabelNames returns the names of labels, in order
+func LabelNames(labels []Label) []string {
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetLabels(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case baseAPIPath + "/pages/456/labels":
+			w.Write([]byte(`{"results":[{"id":"1","name":"runbook","prefix":"global"},{"id":"2","name":"adr","prefix":"global"}]}`))
+		case baseAPIPath + "/blogposts/77/labels":
+			w.Write([]byte(`{"results":[{"id":"3","name":"announcement","prefix":"global"}]}`))
+		case baseAPIPath + "/spaces/123/labels":
+			w.Write([]byte(`{"results":[{"id":"4","name":"engineering","prefix":"team"}]}`))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	pageLabels, err := client.GetPageLabels("456")
+	if err != nil {
+		t.Fatalf("GetPageLabels failed: %v", err)
+	}
+	if names := LabelNames(pageLabels); len(names) != 2 || names[0] != "runbook" || names[1] != "adr" {
+		t.Errorf("Unexpected page labels: %v", names)
+	}
+
+	postLabels, err := client.GetBlogPostLabels("77")
+	if err != nil {
+		t.Fatalf("GetBlogPostLabels failed: %v", err)
+	}
+	if len(postLabels) != 1 || postLabels[0].Name != "announcement" {
+		t.Errorf("Unexpected blog post labels: %+v", postLabels)
+	}
+
+	spaceLabels, err := client.GetSpaceLabels("123")
+	if err != nil {
+		t.Fatalf("GetSpaceLabels failed: %v", err)
+	}
+	if len(spaceLabels) != 1 || spaceLabels[0].Prefix != "team" {
+		t.Errorf("Unexpected space labels: %+v", spaceLabels)
+	}
+}
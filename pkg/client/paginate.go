@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// defaultPageSize is the number of results requested per list call
+	defaultPageSize = 100
+	// maxPageSize is the largest page size the v2 API accepts
+	maxPageSize = 250
+)
+
+// ListOptions controls how list endpoints are requested and paged
+type ListOptions struct {
+	// Limit is the number of results requested per API call.
+	// Zero uses the client's page size (see SetPageSize).
+	Limit int
+	// BodyFormat requests content bodies in the listing (e.g. "storage")
+	// on endpoints that support it.
+	BodyFormat string
+	// Depth limits how many levels below a page are returned on
+	// endpoints that support it (e.g. descendants). Zero uses the API default.
+	Depth int
+	// Query adds endpoint-specific filters, e.g. keys, type or status on spaces.
+	Query url.Values
+}
+
+// SetPageSize sets the default number of results requested per list call.
+// Values are clamped to the range the API accepts.
+func (c *Client) SetPageSize(n int) {
+	c.pageSize = clampPageSize(n)
+}
+
+// PageSize returns the default number of results requested per list call
+func (c *Client) PageSize() int {
+	return c.pageSize
+}
+
+func clampPageSize(n int) int {
+	switch {
+	case n <= 0:
+		return defaultPageSize
+	case n > maxPageSize:
+		return maxPageSize
+	}
+	return n
+}
+
+// values builds the query parameters for the first request of a listing
+func (o *ListOptions) values(c *Client) url.Values {
+	limit := c.pageSize
+	if o != nil && o.Limit > 0 {
+		limit = o.Limit
+	}
+
+	params := url.Values{}
+	if o != nil {
+		for key, values := range o.Query {
+			params[key] = append([]string(nil), values...)
+		}
+	}
+	params.Set("limit", strconv.Itoa(clampPageSize(limit)))
+	if o != nil && o.BodyFormat != "" {
+		params.Set("body-format", o.BodyFormat)
+	}
+	if o != nil && o.Depth > 0 {
+		params.Set("depth", strconv.Itoa(o.Depth))
+	}
+	return params
+}
+
+// listResponse is the envelope shared by all v2 list endpoints, and by the
+// v1 ones, which add offsets the iterators don't need
+type listResponse[T any] struct {
+	Results []T `json:"results"`
+	Links   *struct {
+		Next string `json:"next"`
+	} `json:"_links"`
+}
+
+// paginate returns an iterator over every result of a v2 list endpoint.
+// It yields results as each page arrives and follows the full _links.next
+// URL returned by the API, so every query parameter is carried forward.
+// Iteration stops after the first error, which is yielded with a zero value.
+func paginate[T any](ctx context.Context, c *Client, path string, params url.Values) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		next := c.apiURL(path, params)
+
+		for next != nil {
+			body, err := c.doRequestURL(ctx, "GET", next)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			var response listResponse[T]
+			if err := json.Unmarshal(body, &response); err != nil {
+				yield(zero, fmt.Errorf("failed to parse list response: %w", err))
+				return
+			}
+
+			for _, item := range response.Results {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			next = nil
+			if response.Links != nil && response.Links.Next != "" {
+				if next, err = c.resolveLink(response.Links.Next); err != nil {
+					yield(zero, fmt.Errorf("invalid next link %q: %w", response.Links.Next, err))
+					return
+				}
+			}
+		}
+	}
+}
+
+// paginateV1 returns an iterator over every result of a v1 list endpoint,
+// which pages by offset rather than by cursor. It stops once a response has
+// no _links.next, requesting each page from where the last one ended.
+func paginateV1[T any](ctx context.Context, c *Client, path string, params url.Values) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		params = maps.Clone(params)
+		for start := 0; ; {
+			params.Set("start", strconv.Itoa(start))
+			u := c.apiURL("", params)
+			u.Path = baseAPIv1Path + path
+
+			body, err := c.doRequestURL(ctx, "GET", u)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			var response listResponse[T]
+			if err := json.Unmarshal(body, &response); err != nil {
+				yield(zero, fmt.Errorf("failed to parse list response: %w", err))
+				return
+			}
+
+			for _, item := range response.Results {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if response.Links == nil || response.Links.Next == "" || len(response.Results) == 0 {
+				return
+			}
+			start += len(response.Results)
+		}
+	}
+}
+
+// Collect drains a listing iterator, such as Client.SpacePages, into a slice,
+// stopping at the first error
+func Collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var all []T
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, item)
+	}
+	return all, nil
+}
+
+// resolveLink turns a _links.next value into a request URL on this client's host.
+// Links are normally site-relative ("/wiki/api/v2/..."); absolute links are
+// re-pointed at the configured domain so credentials never leave it.
+func (c *Client) resolveLink(link string) (*url.URL, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	u.Scheme = c.scheme
+	u.Host = c.domain
+	u.User = nil
+	return u, nil
+}
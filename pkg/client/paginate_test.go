@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSpacePagesIteratorFollowsNextLink(t *testing.T) {
+	var requests []string
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+
+		var response PageResponse
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			if r.URL.Query().Get("limit") != "2" {
+				t.Errorf("Expected limit=2 on first request, got %q", r.URL.Query().Get("limit"))
+			}
+			response.Results = []Page{{ID: "1"}, {ID: "2"}}
+			response.Links = &struct {
+				Next string `json:"next"`
+			}{
+				// Relative link carrying extra params, as returned by Confluence
+				Next: baseAPIPath + "/spaces/123/pages?limit=2&sort=id&cursor=page2",
+			}
+		case "page2":
+			if r.URL.Query().Get("sort") != "id" {
+				t.Error("Expected params from the next link to be preserved")
+			}
+			response.Results = []Page{{ID: "3"}}
+		default:
+			t.Errorf("Unexpected cursor %q", r.URL.Query().Get("cursor"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	var ids []string
+	for page, err := range client.SpacePages(context.Background(), "123", &ListOptions{Limit: 2}) {
+		if err != nil {
+			t.Fatalf("Iteration failed: %v", err)
+		}
+		ids = append(ids, page.ID)
+	}
+
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("Expected pages [1 2 3], got %v", ids)
+	}
+
+	if len(requests) != 2 {
+		t.Errorf("Expected 2 requests, got %d", len(requests))
+	}
+}
+
+func TestIteratorStopsEarly(t *testing.T) {
+	callCount := 0
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		response := SpaceResponse{
+			Results: []Space{{ID: fmt.Sprint(callCount)}},
+			Links: &struct {
+				Next string `json:"next"`
+			}{
+				Next: fmt.Sprintf("%s/spaces?cursor=%d", baseAPIPath, callCount),
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	for space, err := range client.Spaces(context.Background(), nil) {
+		if err != nil {
+			t.Fatalf("Iteration failed: %v", err)
+		}
+		if space.ID == "2" {
+			break
+		}
+	}
+
+	if callCount != 2 {
+		t.Errorf("Expected iteration to stop after 2 requests, got %d", callCount)
+	}
+}
+
+func TestIteratorYieldsError(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	var gotErr error
+	for _, err := range client.PageAttachments(context.Background(), "456", nil) {
+		gotErr = err
+	}
+
+	if !IsNotFound(gotErr) {
+		t.Errorf("Expected not-found error from iterator, got %v", gotErr)
+	}
+}
+
+func TestSetPageSize(t *testing.T) {
+	client := NewClient("example.atlassian.net", "user@example.com", "test-token")
+
+	tests := []struct {
+		size     int
+		expected string
+	}{
+		{size: 0, expected: "100"},
+		{size: 25, expected: "25"},
+		{size: 1000, expected: "250"},
+	}
+
+	for _, tt := range tests {
+		client.SetPageSize(tt.size)
+		if got := (*ListOptions)(nil).values(client).Get("limit"); got != tt.expected {
+			t.Errorf("SetPageSize(%d): expected limit %s, got %s", tt.size, tt.expected, got)
+		}
+	}
+}
+
+func TestListOptionsQuery(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("keys"); got != "DOC,ENG" {
+			t.Errorf("Expected keys=DOC,ENG, got %q", got)
+		}
+		if got := r.URL.Query().Get("type"); got != "global" {
+			t.Errorf("Expected type=global, got %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"id":"1","key":"DOC"}]}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	opts := &ListOptions{Query: url.Values{"keys": {"DOC,ENG"}, "type": {"global"}}}
+	for _, err := range client.Spaces(context.Background(), opts) {
+		if err != nil {
+			t.Fatalf("Iteration failed: %v", err)
+		}
+	}
+}
+
+func TestSpacePagesWithBodies(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("body-format"); got != "storage" {
+			t.Errorf("Expected body-format=storage, got %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[
+			{"id":"1","title":"With body","body":{"storage":{"value":"<p>Hi</p>","representation":"storage"}}},
+			{"id":"2","title":"Without body","body":{}}
+		]}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	pages, err := Collect(client.SpacePages(context.Background(), "123", &ListOptions{BodyFormat: "storage"}))
+	if err != nil {
+		t.Fatalf("Listing failed: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("Expected 2 pages, got %d", len(pages))
+	}
+	if !pages[0].HasBody() || pages[0].Body.Storage.Value != "<p>Hi</p>" {
+		t.Errorf("Expected the first page's body from the listing, got %+v", pages[0].Body)
+	}
+	if pages[1].HasBody() {
+		t.Error("Expected a page without a storage body to report none")
+	}
+}
+
+func TestHasBodyTruncated(t *testing.T) {
+	var pages []Page
+	if err := json.Unmarshal([]byte(`[
+		{"id":"1","version":{"number":3},"body":{"storage":{"value":"<p>Hi</p>","representation":"storage"}}},
+		{"id":"2","version":{"number":3},"body":{"storage":{"value":"","representation":"storage"}}},
+		{"id":"3","version":{"number":3},"body":{"storage":{"value":"{\"type\":\"doc\"}","representation":"atlas_doc_format"}}},
+		{"id":"4","body":{"storage":{"value":""}}}
+	]`), &pages); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, false, false, true} {
+		if got := pages[i].HasBody(); got != want {
+			t.Errorf("Page %s: expected HasBody %v, got %v", pages[i].ID, want, got)
+		}
+	}
+
+	var post BlogPost
+	if err := json.Unmarshal([]byte(`{"id":"9","version":{"number":2},"body":{"storage":{"value":""}}}`), &post); err != nil {
+		t.Fatal(err)
+	}
+	if post.HasBody() {
+		t.Error("Expected an edited blog post with an empty body to need fetching")
+	}
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// minAdaptiveRate is the floor the limiter backs off to after repeated 429s
+	minAdaptiveRate = 0.1
+	// recoveryFraction of the configured rate is restored after each success
+	recoveryFraction = 0.05
+)
+
+// rateLimiter is a token bucket shared by every request a Client makes.
+// It adapts to throttling: a 429 halves the current rate and pauses all
+// callers for the server-requested delay, and each successful request
+// gradually restores the configured rate.
+type rateLimiter struct {
+	mu          sync.Mutex
+	limit       float64 // configured requests per second; 0 means unlimited
+	rate        float64 // current requests per second after adaptation
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// newRateLimiter creates a limiter allowing rps requests per second with the given burst.
+// A non-positive rps disables the token bucket but keeps the shared 429 pause.
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if rps < 0 {
+		rps = 0
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		limit:  rps,
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// SetRateLimit limits the client to rps requests per second across all goroutines,
+// allowing bursts of up to burst requests. An rps of 0 removes the limit.
+func (c *Client) SetRateLimit(rps float64, burst int) {
+	c.limiter = newRateLimiter(rps, burst)
+}
+
+// wait blocks until the caller may issue a request or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	var delay time.Duration
+	if now.Before(l.pausedUntil) {
+		delay = l.pausedUntil.Sub(now)
+	}
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		// Reserve a token now; a negative balance is paid back by sleeping
+		l.tokens--
+		if l.tokens < 0 {
+			if d := time.Duration(-l.tokens / l.rate * float64(time.Second)); d > delay {
+				delay = d
+			}
+		}
+	}
+	l.last = now
+	l.mu.Unlock()
+
+	return sleepContext(ctx, delay)
+}
+
+// throttled records a 429 response and pauses all callers for pause
+func (l *rateLimiter) throttled(pause time.Duration) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(pause); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	if l.rate > 0 {
+		l.rate /= 2
+		if l.rate < minAdaptiveRate {
+			l.rate = minAdaptiveRate
+		}
+	}
+}
+
+// succeeded records a successful response, nudging the rate back towards the limit
+func (l *rateLimiter) succeeded() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate > 0 && l.rate < l.limit {
+		l.rate += l.limit * recoveryFraction
+		if l.rate > l.limit {
+			l.rate = l.limit
+		}
+	}
+}
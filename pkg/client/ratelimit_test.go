@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	limiter := newRateLimiter(50, 1)
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		limiter.wait(context.Background())
+	}
+	elapsed := time.Since(start)
+
+	// First request uses the burst token, the remaining 5 wait 20ms each
+	if elapsed < 90*time.Millisecond {
+		t.Errorf("Expected requests to be spaced out, took only %v", elapsed)
+	}
+}
+
+func TestRateLimiterSharedAcrossGoroutines(t *testing.T) {
+	limiter := newRateLimiter(100, 1)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 2; j++ {
+				limiter.wait(context.Background())
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 10 requests at 100/s with burst 1 need at least ~90ms in total
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected goroutines to share one budget, took only %v", elapsed)
+	}
+}
+
+func TestRateLimiterAdapts(t *testing.T) {
+	limiter := newRateLimiter(10, 1)
+
+	limiter.throttled(0)
+	if limiter.rate != 5 {
+		t.Errorf("Expected rate to halve to 5, got %v", limiter.rate)
+	}
+
+	for i := 0; i < 100; i++ {
+		limiter.succeeded()
+	}
+	if limiter.rate != 10 {
+		t.Errorf("Expected rate to recover to 10, got %v", limiter.rate)
+	}
+}
+
+func TestRateLimiterPausesOnThrottle(t *testing.T) {
+	callCount := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		if callCount == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[]}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+	client.SetRetryPolicy(fastRetryPolicy(2))
+	client.SetRateLimit(1000, 10)
+
+	if _, err := client.GetSpaces(); err != nil {
+		t.Fatalf("GetSpaces failed: %v", err)
+	}
+
+	if client.limiter.rate >= 1000 {
+		t.Errorf("Expected limiter to slow down after 429, rate is %v", client.limiter.rate)
+	}
+}
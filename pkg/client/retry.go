@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried.
+// Only idempotent requests (GET, HEAD, OPTIONS) are ever retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 1 are treated as 1 (no retries).
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; it doubles on each attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps both the computed backoff and any server-requested delay.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used by NewClient
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    60 * time.Second,
+	}
+}
+
+// SetRetryPolicy replaces the client's retry policy
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// send executes req with hc, retrying transient failures according to the retry policy.
+// Non-2xx responses that are not retried (or that exhaust the policy) are
+// returned to the caller unchanged so it can build a meaningful error.
+func (c *Client) send(hc *http.Client, req *http.Request) (*http.Response, error) {
+	attempts := c.retryPolicy.MaxAttempts
+	if attempts < 1 || !isIdempotent(req.Method) {
+		attempts = 1
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		release, err := c.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := hc.Do(req)
+		if err != nil {
+			release()
+		}
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				c.limiter.succeeded()
+			}
+			resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		}
+		if err != nil && (ctx.Err() != nil || !isTransientError(err)) {
+			return nil, err
+		}
+		if attempt >= attempts {
+			if resp != nil {
+				resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+			}
+			return resp, err
+		}
+
+		delay := c.retryPolicy.backoff(attempt)
+		if resp != nil {
+			if d, ok := retryAfter(resp.Header, time.Now()); ok {
+				delay = d
+			}
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			release()
+		}
+		if c.retryPolicy.MaxDelay > 0 && delay > c.retryPolicy.MaxDelay {
+			delay = c.retryPolicy.MaxDelay
+		}
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			// Slow down every goroutine sharing this client, not just this one
+			c.limiter.throttled(delay)
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleepContext pauses for d, returning early with ctx's error if it is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backoff returns the exponential backoff with full jitter for the given attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	ceiling := p.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || (p.MaxDelay > 0 && ceiling > p.MaxDelay) {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// retryAfter extracts the server-requested delay from a throttled response.
+// It understands the standard Retry-After header (seconds or HTTP date),
+// Atlassian's Beta-Retry-After header, and the X-RateLimit-Reset timestamp.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	for _, name := range []string{"Retry-After", "Beta-Retry-After"} {
+		v := h.Get(name)
+		if v == "" {
+			continue
+		}
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return nonNegative(t.Sub(now)), true
+		}
+	}
+
+	if h.Get("X-RateLimit-Remaining") == "0" || h.Get("X-RateLimit-NearLimit") == "true" {
+		if v := h.Get("X-RateLimit-Reset"); v != "" {
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return nonNegative(t.Sub(now)), true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// isIdempotent reports whether requests with this method are safe to repeat
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// isRetryableStatus reports whether a response status is worth retrying
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isTransientError reports whether a transport error is likely to succeed on retry
+func isTransientError(err error) bool {
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fastRetryPolicy retries quickly so tests don't sleep
+func fastRetryPolicy(attempts int) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: attempts,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	}
+}
+
+func TestRetryOnTransientStatus(t *testing.T) {
+	callCount := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		switch callCount {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"results":[{"id":"1","key":"TEST"}]}`))
+		}
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+	client.SetRetryPolicy(fastRetryPolicy(3))
+
+	spaces, err := client.GetSpaces()
+	if err != nil {
+		t.Fatalf("GetSpaces failed: %v", err)
+	}
+
+	if len(spaces) != 1 {
+		t.Fatalf("Expected 1 space, got %d", len(spaces))
+	}
+
+	if callCount != 3 {
+		t.Errorf("Expected 3 attempts, got %d", callCount)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	callCount := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+	client.SetRetryPolicy(fastRetryPolicy(2))
+
+	if _, err := client.GetSpaces(); err == nil {
+		t.Fatal("Expected error after exhausting retries")
+	}
+
+	if callCount != 2 {
+		t.Errorf("Expected 2 attempts, got %d", callCount)
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	callCount := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+	client.SetRetryPolicy(fastRetryPolicy(5))
+
+	if _, err := client.GetPage("missing"); err == nil {
+		t.Fatal("Expected error for 404")
+	}
+
+	if callCount != 1 {
+		t.Errorf("Expected a single attempt for 404, got %d", callCount)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected time.Duration
+		ok       bool
+	}{
+		{
+			name:     "Seconds",
+			headers:  map[string]string{"Retry-After": "7"},
+			expected: 7 * time.Second,
+			ok:       true,
+		},
+		{
+			name:     "HTTP date",
+			headers:  map[string]string{"Retry-After": now.Add(30 * time.Second).Format(http.TimeFormat)},
+			expected: 30 * time.Second,
+			ok:       true,
+		},
+		{
+			name:     "Beta-Retry-After",
+			headers:  map[string]string{"Beta-Retry-After": "3"},
+			expected: 3 * time.Second,
+			ok:       true,
+		},
+		{
+			name: "Rate limit reset",
+			headers: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     now.Add(time.Minute).Format(time.RFC3339),
+			},
+			expected: time.Minute,
+			ok:       true,
+		},
+		{
+			name:    "No headers",
+			headers: map[string]string{},
+			ok:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+
+			d, ok := retryAfter(h, now)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok=%v, got %v", tt.ok, ok)
+			}
+			if d != tt.expected {
+				t.Errorf("Expected delay %v, got %v", tt.expected, d)
+			}
+		})
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := policy.backoff(attempt)
+		if d <= 0 || d > time.Second {
+			t.Errorf("Attempt %d: backoff %v out of bounds", attempt, d)
+		}
+	}
+}
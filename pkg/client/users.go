@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// User represents an Atlassian account
+type User struct {
+	AccountID   string `json:"accountId"`
+	DisplayName string `json:"displayName"`
+	PublicName  string `json:"publicName"`
+	// Email is only returned when the user's profile visibility allows it
+	Email string `json:"email"`
+}
+
+// Name returns the best available human-readable name for the user
+func (u *User) Name() string {
+	switch {
+	case u.DisplayName != "":
+		return u.DisplayName
+	case u.PublicName != "":
+		return u.PublicName
+	}
+	return u.AccountID
+}
+
+// GetUser retrieves a user by Atlassian account ID
+func (c *Client) GetUser(accountID string) (*User, error) {
+	return c.GetUserContext(context.Background(), accountID)
+}
+
+// GetUserContext retrieves a user by Atlassian account ID, aborting if ctx is cancelled.
+// The v2 API has no GET endpoint for users, so this uses the v1 user API.
+func (c *Client) GetUserContext(ctx context.Context, accountID string) (*User, error) {
+	params := url.Values{}
+	params.Set("accountId", accountID)
+
+	u := c.apiURL("", params)
+	u.Path = baseAPIv1Path + "/user"
+
+	body, err := c.doRequestURL(ctx, "GET", u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", accountID, err)
+	}
+
+	var user User
+	if err := json.Unmarshal(body, &user); err != nil {
+		return nil, fmt.Errorf("failed to parse user response: %w", err)
+	}
+
+	return &user, nil
+}
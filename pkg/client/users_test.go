@@ -0,0 +1,51 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetUser(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Users are only available through the v1 API
+		expectedPath := baseAPIv1Path + "/user"
+		if r.URL.Path != expectedPath {
+			t.Errorf("Expected path %s, got %s", expectedPath, r.URL.Path)
+		}
+		if r.URL.Query().Get("accountId") != "acc-1" {
+			t.Errorf("Expected accountId=acc-1, got %q", r.URL.Query().Get("accountId"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"accountId":"acc-1","displayName":"Ada Lovelace","publicName":"ada","email":"ada@example.com"}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	user, err := client.GetUser("acc-1")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+
+	if user.Name() != "Ada Lovelace" || user.Email != "ada@example.com" {
+		t.Errorf("Unexpected user: %+v", user)
+	}
+}
+
+func TestUserName(t *testing.T) {
+	tests := []struct {
+		user     User
+		expected string
+	}{
+		{user: User{AccountID: "a", DisplayName: "Display", PublicName: "public"}, expected: "Display"},
+		{user: User{AccountID: "a", PublicName: "public"}, expected: "public"},
+		{user: User{AccountID: "a"}, expected: "a"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.user.Name(); got != tt.expected {
+			t.Errorf("Name() = %q, want %q", got, tt.expected)
+		}
+	}
+}
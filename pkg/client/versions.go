@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+)
+
+// GetPageVersions retrieves the version history of a page
+func (c *Client) GetPageVersions(pageID string) ([]Version, error) {
+	return c.GetPageVersionsContext(context.Background(), pageID)
+}
+
+// GetPageVersionsContext retrieves the version history of a page, aborting if ctx is cancelled
+func (c *Client) GetPageVersionsContext(ctx context.Context, pageID string) ([]Version, error) {
+	versions, err := Collect(c.PageVersions(ctx, pageID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get versions for page %s: %w", pageID, err)
+	}
+	return versions, nil
+}
+
+// PageVersions returns an iterator over the versions of a page, fetching them page by page
+func (c *Client) PageVersions(ctx context.Context, pageID string, opts *ListOptions) iter.Seq2[Version, error] {
+	path := fmt.Sprintf("/pages/%s/versions", pageID)
+	return paginate[Version](ctx, c, path, opts.values(c))
+}
+
+// GetPageAtVersion retrieves a page as it was at a historical version, including its body
+func (c *Client) GetPageAtVersion(pageID string, version int) (*Page, error) {
+	return c.GetPageAtVersionContext(context.Background(), pageID, version)
+}
+
+// GetPageAtVersionContext retrieves a page as it was at a historical version, aborting if ctx is cancelled
+func (c *Client) GetPageAtVersionContext(ctx context.Context, pageID string, version int) (*Page, error) {
+	params := url.Values{}
+	params.Set("body-format", "storage")
+	params.Set("version", strconv.Itoa(version))
+
+	path := fmt.Sprintf("/pages/%s", pageID)
+	body, err := c.doRequest(ctx, "GET", path, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page %s at version %d: %w", pageID, version, err)
+	}
+
+	var page Page
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse page response: %w", err)
+	}
+
+	return &page, nil
+}
+
+// GetBlogPostVersions retrieves the version history of a blog post
+func (c *Client) GetBlogPostVersions(blogPostID string) ([]Version, error) {
+	return c.GetBlogPostVersionsContext(context.Background(), blogPostID)
+}
+
+// GetBlogPostVersionsContext retrieves the version history of a blog post, aborting if ctx is cancelled
+func (c *Client) GetBlogPostVersionsContext(ctx context.Context, blogPostID string) ([]Version, error) {
+	versions, err := Collect(c.BlogPostVersions(ctx, blogPostID, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get versions for blog post %s: %w", blogPostID, err)
+	}
+	return versions, nil
+}
+
+// BlogPostVersions returns an iterator over the versions of a blog post, fetching them page by page
+func (c *Client) BlogPostVersions(ctx context.Context, blogPostID string, opts *ListOptions) iter.Seq2[Version, error] {
+	path := fmt.Sprintf("/blogposts/%s/versions", blogPostID)
+	return paginate[Version](ctx, c, path, opts.values(c))
+}
+
+// GetBlogPostAtVersion retrieves a blog post as it was at a historical version, including its body
+func (c *Client) GetBlogPostAtVersion(blogPostID string, version int) (*BlogPost, error) {
+	return c.GetBlogPostAtVersionContext(context.Background(), blogPostID, version)
+}
+
+// GetBlogPostAtVersionContext retrieves a blog post as it was at a historical version, aborting if ctx is cancelled
+func (c *Client) GetBlogPostAtVersionContext(ctx context.Context, blogPostID string, version int) (*BlogPost, error) {
+	params := url.Values{}
+	params.Set("body-format", "storage")
+	params.Set("version", strconv.Itoa(version))
+
+	path := fmt.Sprintf("/blogposts/%s", blogPostID)
+	body, err := c.doRequest(ctx, "GET", path, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blog post %s at version %d: %w", blogPostID, version, err)
+	}
+
+	var post BlogPost
+	if err := json.Unmarshal(body, &post); err != nil {
+		return nil, fmt.Errorf("failed to parse blog post response: %w", err)
+	}
+
+	return &post, nil
+}
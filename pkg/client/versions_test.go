@@ -0,0 +1,110 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetPageVersions(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify path
+		expectedPath := baseAPIPath + "/pages/456/versions"
+		if r.URL.Path != expectedPath {
+			t.Errorf("Expected path %s, got %s", expectedPath, r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[
+			{"number":2,"message":"Tighten policy","minorEdit":false,"authorId":"acc-2","createdAt":"2025-02-01T00:00:00.000Z"},
+			{"number":1,"message":"","minorEdit":true,"authorId":"acc-1","createdAt":"2025-01-01T00:00:00.000Z"}
+		]}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	versions, err := client.GetPageVersions("456")
+	if err != nil {
+		t.Fatalf("GetPageVersions failed: %v", err)
+	}
+
+	if len(versions) != 2 {
+		t.Fatalf("Expected 2 versions, got %d", len(versions))
+	}
+
+	if versions[0].Number != 2 || versions[0].AuthorID != "acc-2" || versions[0].Message != "Tighten policy" {
+		t.Errorf("Unexpected version: %+v", versions[0])
+	}
+
+	if !versions[1].MinorEdit {
+		t.Error("Expected version 1 to be a minor edit")
+	}
+}
+
+func TestGetPageAtVersion(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify path and parameters
+		expectedPath := baseAPIPath + "/pages/456"
+		if r.URL.Path != expectedPath {
+			t.Errorf("Expected path %s, got %s", expectedPath, r.URL.Path)
+		}
+		if r.URL.Query().Get("version") != "1" {
+			t.Errorf("Expected version=1 parameter, got %q", r.URL.Query().Get("version"))
+		}
+		if r.URL.Query().Get("body-format") != "storage" {
+			t.Error("Expected body-format=storage parameter")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"456","title":"Policy","version":{"number":1},"body":{"storage":{"value":"<p>Old text</p>","representation":"storage"}}}`))
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	page, err := client.GetPageAtVersion("456", 1)
+	if err != nil {
+		t.Fatalf("GetPageAtVersion failed: %v", err)
+	}
+
+	if page.Body == nil || page.Body.Storage == nil || page.Body.Storage.Value != "<p>Old text</p>" {
+		t.Error("Expected historical body to be present")
+	}
+}
+
+func TestGetBlogPostVersionHistory(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case baseAPIPath + "/blogposts/77/versions":
+			w.Write([]byte(`{"results":[{"number":2,"authorId":"acc-2"},{"number":1,"authorId":"acc-1"}]}`))
+		case baseAPIPath + "/blogposts/77":
+			if r.URL.Query().Get("version") != "1" {
+				t.Errorf("Expected version=1 parameter, got %q", r.URL.Query().Get("version"))
+			}
+			w.Write([]byte(`{"id":"77","title":"Team Update","version":{"number":1},"body":{"storage":{"value":"<p>Draft</p>","representation":"storage"}}}`))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+
+	client, server := setupTest(t, handler)
+	defer server.Close()
+
+	versions, err := client.GetBlogPostVersions("77")
+	if err != nil {
+		t.Fatalf("GetBlogPostVersions failed: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Number != 2 {
+		t.Errorf("Unexpected versions: %+v", versions)
+	}
+
+	post, err := client.GetBlogPostAtVersion("77", 1)
+	if err != nil {
+		t.Fatalf("GetBlogPostAtVersion failed: %v", err)
+	}
+	if !post.HasBody() || post.Body.Storage.Value != "<p>Draft</p>" {
+		t.Errorf("Unexpected blog post body: %+v", post.Body)
+	}
+}
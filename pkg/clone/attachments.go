@@ -0,0 +1,120 @@
+package clone
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+// AttachmentFilters decide which attachments are downloaded. Attachments
+// that aren't are listed under skippedAttachments in their page's metadata.
+// Empty fields match everything.
+type AttachmentFilters struct {
+	MaxSize           int64    // Largest file downloaded, in bytes; 0 for no limit
+	IncludeMediaTypes []string // Media types or glob patterns (e.g. "image/*") to download
+	ExcludeMediaTypes []string // Media types or glob patterns to skip
+	IncludeExtensions []string // File extensions (e.g. "pdf") to download
+	ExcludeExtensions []string // File extensions to skip
+	MetadataOnly      bool     // Write each attachment's .json sidecar but not the file itself
+}
+
+// skippedAttachment records an attachment that wasn't downloaded, and why
+type skippedAttachment struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	MediaType string `json:"mediaType"`
+	FileSize  int64  `json:"fileSize"`
+	Reason    string `json:"reason"`
+}
+
+// skipReason returns why an attachment isn't downloaded, or "" if it is
+func (f *AttachmentFilters) skipReason(attachment client.Attachment) string {
+	if f.MaxSize > 0 && attachment.FileSize > f.MaxSize {
+		return fmt.Sprintf("larger than %s", formatBytes(f.MaxSize))
+	}
+
+	mediaType, _, _ := strings.Cut(attachment.MediaType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	if len(f.IncludeMediaTypes) > 0 && !matchAny(f.IncludeMediaTypes, mediaType) {
+		return fmt.Sprintf("media type %q not included", mediaType)
+	}
+	if matchAny(f.ExcludeMediaTypes, mediaType) {
+		return fmt.Sprintf("media type %q excluded", mediaType)
+	}
+
+	ext := strings.TrimPrefix(filepath.Ext(attachment.Title), ".")
+	if len(f.IncludeExtensions) > 0 && !matchAny(trimDots(f.IncludeExtensions), ext) {
+		return fmt.Sprintf("extension %q not included", ext)
+	}
+	if matchAny(trimDots(f.ExcludeExtensions), ext) {
+		return fmt.Sprintf("extension %q excluded", ext)
+	}
+
+	if f.MetadataOnly {
+		return "metadata only"
+	}
+	return ""
+}
+
+// trimDots strips the leading dot from extensions given as ".pdf"
+func trimDots(exts []string) []string {
+	trimmed := make([]string, len(exts))
+	for i, ext := range exts {
+		trimmed[i] = strings.TrimPrefix(ext, ".")
+	}
+	return trimmed
+}
+
+// saveAttachmentSidecar writes an attachment's metadata next to where its file
+// goes, recording the file name its title was saved as. With the blob store
+// on, hash names the blob holding its content.
+func saveAttachmentSidecar(attachmentsDir string, attachment client.Attachment, file, hash string) error {
+	metadataPath := filepath.Join(attachmentsDir, file+".json")
+	metadata := map[string]interface{}{
+		"id":        attachment.ID,
+		"title":     attachment.Title,
+		"file":      file,
+		"type":      attachment.Type,
+		"status":    attachment.Status,
+		"mediaType": attachment.MediaType,
+		"fileSize":  attachment.FileSize,
+	}
+	if hash != "" {
+		metadata["sha256"] = hash
+		metadata["blob"] = blobRef(hash)
+	}
+	return saveJSON(metadataPath, metadata)
+}
+
+// recordSkippedAttachments lists the attachments that weren't downloaded in
+// the metadata.json in dir, so the gap is visible in the export
+func recordSkippedAttachments(dir string, skipped []skippedAttachment) error {
+	metadataPath := filepath.Join(dir, "metadata.json")
+	data, err := os.ReadFile(metadataPath)
+	if err != nil {
+		return err
+	}
+
+	// Keep numbers as written rather than round-tripping them through float64
+	var metadata map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&metadata); err != nil {
+		return fmt.Errorf("invalid %s: %w", metadataPath, err)
+	}
+
+	if _, recorded := metadata["skippedAttachments"]; !recorded && len(skipped) == 0 {
+		return nil
+	}
+	if len(skipped) == 0 {
+		delete(metadata, "skippedAttachments")
+	} else {
+		metadata["skippedAttachments"] = skipped
+	}
+	return saveJSON(metadataPath, metadata)
+}
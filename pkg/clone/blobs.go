@@ -0,0 +1,337 @@
+package clone
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+// BlobStore controls whether attachments are kept once each in a
+// content-addressed store under blobs/ in the output directory
+type BlobStore string
+
+const (
+	// BlobStoreOff saves every attachment as its own file under its page
+	BlobStoreOff BlobStore = "off"
+	// BlobStoreHardlink makes each page's attachment a hard link to its blob
+	BlobStoreHardlink BlobStore = "hardlink"
+	// BlobStoreReference leaves only the sidecar under the page, naming the blob
+	BlobStoreReference BlobStore = "reference"
+)
+
+// blobDirName is the directory under the output root that holds the blob store
+const blobDirName = "blobs"
+
+// ParseBlobStore parses a blob store mode, accepting "" as off
+func ParseBlobStore(s string) (BlobStore, error) {
+	switch m := BlobStore(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return BlobStoreOff, nil
+	case BlobStoreOff, BlobStoreHardlink, BlobStoreReference:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown blob store mode %q (want %q, %q or %q)", s, BlobStoreOff, BlobStoreHardlink, BlobStoreReference)
+}
+
+// blobStoreEnabled reports whether attachments go through the blob store
+func (cl *Cloner) blobStoreEnabled() bool {
+	return cl.BlobStore == BlobStoreHardlink || cl.BlobStore == BlobStoreReference
+}
+
+// blobRef names a blob relative to the output root, as recorded in sidecars
+func blobRef(hash string) string {
+	return blobDirName + "/sha256/" + hash[:2] + "/" + hash
+}
+
+// blobPath returns where a blob is stored
+func (cl *Cloner) blobPath(hash string) string {
+	return filepath.Join(cl.outputDir, filepath.FromSlash(blobRef(hash)))
+}
+
+// storeBlob streams r into the blob store and returns its SHA-256. Content
+// already in the store is not written again. If expectedSize is positive, the
+// number of bytes read must match it or nothing is stored.
+func (cl *Cloner) storeBlob(r io.Reader, expectedSize int64) (string, error) {
+	stagingDir := filepath.Join(cl.outputDir, blobDirName, "sha256")
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(stagingDir, ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if expectedSize > 0 && n != expectedSize {
+		tmp.Close()
+		return "", fmt.Errorf("size mismatch: expected %d bytes, got %d", expectedSize, n)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	path := cl.blobPath(hash)
+	if fileExists(path) {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return hash, os.Rename(tmp.Name(), path)
+}
+
+// storeFile moves a file saved before the blob store was enabled into it,
+// returning its SHA-256
+func (cl *Cloner) storeFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return cl.storeBlob(f, -1)
+}
+
+// placeBlob puts a stored blob at path as the blob store mode asks: a hard
+// link to it, or nothing at all when the sidecar is the only reference. File
+// systems without hard links get a copy instead.
+func (cl *Cloner) placeBlob(hash, path string) error {
+	if cl.BlobStore == BlobStoreReference {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	blob := cl.blobPath(hash)
+	if a, err := os.Stat(path); err == nil {
+		if b, err := os.Stat(blob); err == nil && os.SameFile(a, b) {
+			return nil
+		}
+	}
+
+	// Link under a temporary name and rename it into place, so an existing
+	// file is replaced atomically
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	os.Remove(tmp.Name())
+	if err := os.Link(blob, tmp.Name()); err != nil {
+		cl.linkWarning.Do(func() {
+			cl.logf("      Warning: Hard links unavailable, copying attachments out of the blob store instead: %v\n", err)
+		})
+		return copyBlob(blob, path)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// attachmentSidecar is the part of an attachment's sidecar the blob store reads
+type attachmentSidecar struct {
+	SHA256 string `json:"sha256"`
+}
+
+// sidecarHash returns the blob an attachment's sidecar names, or "" if none
+func sidecarHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var sidecar attachmentSidecar
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return sidecar.SHA256, nil
+}
+
+// storedAttachment reports whether an attachment is already in the output
+// through the blob store, putting it in place as the current mode asks. A
+// file downloaded before the store was enabled is moved into it.
+func (cl *Cloner) storedAttachment(attachmentsDir string, attachment client.Attachment, file string) bool {
+	filePath := filepath.Join(attachmentsDir, file)
+	hash, _ := sidecarHash(filePath + ".json")
+	if hash == "" || !fileExists(cl.blobPath(hash)) {
+		if !fileExists(filePath) {
+			return false
+		}
+		var err error
+		if hash, err = cl.storeFile(filePath); err != nil {
+			cl.logf("      Warning: Failed to move %s into the blob store: %v\n", attachment.Title, err)
+			return true
+		}
+		if err := saveAttachmentSidecar(attachmentsDir, attachment, file, hash); err != nil {
+			cl.logf("      Warning: Failed to save attachment metadata: %v\n", err)
+			return true
+		}
+	}
+
+	if err := cl.placeBlob(hash, filePath); err != nil {
+		cl.logf("      Warning: Failed to place %s from the blob store: %v\n", attachment.Title, err)
+		return false
+	}
+	return true
+}
+
+// unstoredAttachment reports whether an attachment is already in the output
+// as a plain file, with the blob store off. One an earlier run kept in the
+// store is copied out in place of its reference stub or hard link, and its
+// sidecar is rewritten without the blob.
+func (cl *Cloner) unstoredAttachment(attachmentsDir string, attachment client.Attachment, file string) bool {
+	filePath := filepath.Join(attachmentsDir, file)
+	hash, _ := sidecarHash(filePath + ".json")
+	if hash == "" {
+		return fileExists(filePath)
+	}
+
+	// Copy the blob out in place of a reference stub or a hard link to it
+	blob := cl.blobPath(hash)
+	stored, blobErr := os.Stat(blob)
+	onDisk, fileErr := os.Stat(filePath)
+	switch {
+	case blobErr != nil && fileErr != nil:
+		return false
+	case blobErr == nil && (fileErr != nil || os.SameFile(onDisk, stored)):
+		if err := copyBlob(blob, filePath); err != nil {
+			cl.logf("      Warning: Failed to copy %s out of the blob store: %v\n", attachment.Title, err)
+			return false
+		}
+	}
+	if err := saveAttachmentSidecar(attachmentsDir, attachment, file, ""); err != nil {
+		cl.logf("      Warning: Failed to save attachment metadata: %v\n", err)
+	}
+	return true
+}
+
+// copyBlob writes a copy of a stored blob to path
+func copyBlob(blob, path string) error {
+	f, err := os.Open(blob)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = writeStreamAtomic(path, f, -1)
+	return err
+}
+
+// CollectBlobs removes blobs that no attachment sidecar in the output names,
+// including sidecars kept under tombstones, and returns how many were
+// removed and the bytes freed. Nothing is removed if any sidecar can't be read.
+func (cl *Cloner) CollectBlobs() (int, int64, error) {
+	storeDir := filepath.Join(cl.outputDir, blobDirName)
+
+	// Gather every blob a sidecar still names
+	referenced := make(map[string]bool)
+	err := filepath.WalkDir(cl.outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == storeDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".json" || filepath.Base(filepath.Dir(path)) != "attachments" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// An attachment that is itself JSON isn't a sidecar, and may not parse
+		var sidecar attachmentSidecar
+		if json.Unmarshal(data, &sidecar) == nil && sidecar.SHA256 != "" {
+			referenced[sidecar.SHA256] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to scan attachment metadata: %w", err)
+	}
+
+	// Remove the rest, along with temporary files left by interrupted runs
+	removed, freed := 0, int64(0)
+	err = filepath.WalkDir(storeDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == storeDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || referenced[d.Name()] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(d.Name(), ".") {
+			removed++
+			freed += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, freed, err
+	}
+
+	// Drop the fan-out directories left empty, and the store itself once the
+	// blob store is off and nothing in it is used
+	fanOut, err := os.ReadDir(filepath.Join(storeDir, "sha256"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return removed, freed, nil
+		}
+		return removed, freed, err
+	}
+	for _, d := range fanOut {
+		if d.IsDir() {
+			if err := removeEmptyDir(filepath.Join(storeDir, "sha256", d.Name())); err != nil {
+				return removed, freed, err
+			}
+		}
+	}
+	if !cl.blobStoreEnabled() {
+		for _, dir := range []string{filepath.Join(storeDir, "sha256"), storeDir} {
+			if err := removeEmptyDir(dir); err != nil {
+				return removed, freed, err
+			}
+		}
+	}
+	return removed, freed, nil
+}
+
+// removeEmptyDir removes the directory at path if nothing is left in it
+func removeEmptyDir(path string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil || len(entries) > 0 {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,114 @@
+package clone
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+// cloneBlogPosts clones every blog post in a space into spaceDir/blogposts
+func (cl *Cloner) cloneBlogPosts(ctx context.Context, space client.Space, spaceDir string, state *spaceState) error {
+	// Blog posts sit outside the page tree, so a subtree export has none
+	if len(cl.Filters.SubtreeRoots) > 0 {
+		return nil
+	}
+
+	current, posts, err := cl.selectBlogPosts(ctx, space)
+	if err != nil {
+		return err
+	}
+
+	blogDir := filepath.Join(spaceDir, "blogposts")
+	if len(current) > 0 {
+		cl.logf("  Found %d blog post(s)\n", len(current))
+		if err := os.MkdirAll(blogDir, 0755); err != nil {
+			return fmt.Errorf("failed to create blog posts directory: %w", err)
+		}
+
+		err = cl.forEachConcurrent(ctx, cl.pagePool, len(current), func(j int) {
+			post := current[j]
+			cl.logf("  [%d/%d] Cloning blog post: %s\n", j+1, len(current), post.Title)
+
+			if err := cl.cloneBlogPost(ctx, post, spaceDir, space.Key, state); err != nil && ctx.Err() == nil {
+				cl.logContentError("blog post", post.Title, err)
+				cl.checkpoint.fail(checkpointBlogPost, post.ID, err)
+			}
+			current[j].Body = nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Deal with blog posts deleted or renamed upstream
+	if !cl.completeListing() {
+		return nil
+	}
+	live := make(map[string]bool, len(posts))
+	for _, post := range posts {
+		live[post.ID] = true
+	}
+	if stale, err := staleBlogPostDirs(blogDir, posts); err != nil {
+		cl.logf("  Warning: Failed to check for deleted blog posts: %v\n", err)
+	} else {
+		cl.handleStale(blogDir, stale, "blog post")
+	}
+	state.prune(state.BlogPosts, live)
+
+	return nil
+}
+
+// selectBlogPosts lists a space's blog posts and picks the ones to clone,
+// after the archived policy, filters and sampling. It also returns every
+// blog post listed, for deletion detection.
+func (cl *Cloner) selectBlogPosts(ctx context.Context, space client.Space) ([]client.BlogPost, []client.BlogPost, error) {
+	cl.logf("  Fetching blog posts...\n")
+	listOpts := &client.ListOptions{Query: cl.contentQuery()}
+	if cl.listSpaceBodies(space) {
+		listOpts.BodyFormat = "storage"
+	}
+	posts, err := client.Collect(cl.client.SpaceBlogPosts(ctx, space.ID, listOpts))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get blog posts: %w", err)
+	}
+
+	// Skip archived blog posts unless asked for and apply the title and date filters, matching pages
+	current := make([]client.BlogPost, 0, len(posts))
+	for _, post := range posts {
+		if post.Status == "archived" && !cl.includeArchivedContent() {
+			continue
+		}
+		if !cl.Filters.matchContent(post.Title, post.Version) {
+			continue
+		}
+		current = append(current, post)
+	}
+
+	// Sample blog posts with the same budget as pages
+	current = cl.sampleBlogPosts(space, current)
+	return current, posts, nil
+}
+
+// cloneBlogPost clones a single blog post
+func (cl *Cloner) cloneBlogPost(ctx context.Context, post client.BlogPost, spaceDir string, spaceKey string, state *spaceState) error {
+	return cl.cloneContent(ctx, blogPostContent{post}, contentTarget{
+		spaceDir: spaceDir,
+		spaceKey: spaceKey,
+		relDir:   filepath.Join("blogposts", blogPostDirName(post)),
+		state:    state,
+		items:    state.BlogPosts,
+	})
+}
+
+// blogPostDirName names a blog post directory by publication date so posts sort chronologically
+func blogPostDirName(post client.BlogPost) string {
+	date := "undated"
+	if len(post.CreatedAt) >= len("2006-01-02") {
+		date = post.CreatedAt[:len("2006-01-02")]
+	}
+	prefix := fmt.Sprintf("%s_%s_", date, post.ID)
+	return prefix + truncateBytes(cleanName(post.Title), blogPostDirBytes-len(prefix))
+}
@@ -0,0 +1,208 @@
+package clone
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+const (
+	// checkpointFileName is the resume journal, kept at the output root while a run is in progress
+	checkpointFileName = "checkpoint.json"
+	// checkpointFlushInterval bounds how much progress a crash can lose
+	checkpointFlushInterval = 2 * time.Second
+)
+
+// Kinds of work recorded in the checkpoint journal
+const (
+	checkpointSpace      = "space"
+	checkpointPage       = "page"
+	checkpointBlogPost   = "blogpost"
+	checkpointAttachment = "attachment"
+)
+
+// checkpoint is a journal of the work a run has completed or failed, so an
+// interrupted run can be resumed. It is rewritten atomically, at most every
+// checkpointFlushInterval, so a crash never leaves a corrupt journal behind.
+type checkpoint struct {
+	mu        sync.Mutex
+	path      string
+	lastFlush time.Time
+	dirty     bool
+	failCount int
+	writeErr  error // First failed periodic write, reported by flush
+
+	StartedAt time.Time                    `json:"startedAt"`
+	Completed map[string]map[string]int    `json:"completed"` // kind -> ID -> version
+	Failed    map[string]map[string]string `json:"failed"`    // kind -> ID -> error
+}
+
+// openCheckpoint starts a journal in outputDir. When resuming, the previous
+// run's journal is loaded so its completed work can be skipped.
+func openCheckpoint(outputDir string, resume bool) (*checkpoint, error) {
+	cp := &checkpoint{
+		path:      filepath.Join(outputDir, checkpointFileName),
+		StartedAt: time.Now().UTC(),
+		Completed: make(map[string]map[string]int),
+		Failed:    make(map[string]map[string]string),
+	}
+	if !resume {
+		return cp, nil
+	}
+
+	data, err := os.ReadFile(cp.path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return cp, err
+	}
+
+	var previous checkpoint
+	if err := json.Unmarshal(data, &previous); err != nil {
+		return cp, fmt.Errorf("invalid %s: %w", checkpointFileName, err)
+	}
+	if previous.Completed != nil {
+		cp.Completed = previous.Completed
+	}
+	cp.StartedAt = previous.StartedAt
+	// Failed items are retried, so their errors start afresh
+	return cp, nil
+}
+
+// count returns how many items of a kind are recorded as completed
+func (cp *checkpoint) count(kind string) int {
+	if cp == nil {
+		return 0
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return len(cp.Completed[kind])
+}
+
+// done reports whether an item was completed at this version
+func (cp *checkpoint) done(kind, id string, version int) bool {
+	if cp == nil {
+		return false
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	v, ok := cp.Completed[kind][id]
+	return ok && v == version
+}
+
+// complete records an item as finished
+func (cp *checkpoint) complete(kind, id string, version int) {
+	if cp == nil {
+		return
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if cp.Completed[kind] == nil {
+		cp.Completed[kind] = make(map[string]int)
+	}
+	cp.Completed[kind][id] = version
+	if failed := cp.Failed[kind]; failed != nil {
+		delete(failed, id)
+	}
+	cp.changed()
+}
+
+// fail records an item that couldn't be cloned, so a resumed run retries it
+func (cp *checkpoint) fail(kind, id string, err error) {
+	if cp == nil {
+		return
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if cp.Failed[kind] == nil {
+		cp.Failed[kind] = make(map[string]string)
+	}
+	cp.Failed[kind][id] = err.Error()
+	cp.failCount++
+	cp.changed()
+}
+
+// failures returns the number of items that failed in this run
+func (cp *checkpoint) failures() int {
+	if cp == nil {
+		return 0
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.failCount
+}
+
+// changed marks the journal dirty and flushes it if the last write is old enough.
+// A failed write is kept for flush to report. The caller must hold cp.mu.
+func (cp *checkpoint) changed() {
+	cp.dirty = true
+	if time.Since(cp.lastFlush) >= checkpointFlushInterval {
+		if err := cp.writeLocked(); err != nil && cp.writeErr == nil {
+			cp.writeErr = err
+		}
+	}
+}
+
+// flush writes any unsaved progress, reporting the first write that failed
+// during the run even if this one succeeds
+func (cp *checkpoint) flush() error {
+	if cp == nil {
+		return nil
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	err := cp.writeErr
+	cp.writeErr = nil
+	if cp.dirty {
+		if writeErr := cp.writeLocked(); writeErr != nil {
+			return writeErr
+		}
+	}
+	return err
+}
+
+func (cp *checkpoint) writeLocked() error {
+	cp.lastFlush = time.Now()
+	if err := saveJSON(cp.path, cp); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	cp.dirty = false
+	return nil
+}
+
+// remove deletes the journal once a run has finished cleanly
+func (cp *checkpoint) remove() error {
+	if cp == nil {
+		return nil
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.dirty = false
+	if err := os.Remove(cp.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// resumed reports whether an item can be skipped because an interrupted run already
+// finished it at this version and its files are still in dir
+func (cl *Cloner) resumed(kind, id string, version *client.Version, dir string) bool {
+	if !cl.Resume || !cl.checkpoint.done(kind, id, versionNumber(version)) {
+		return false
+	}
+	return fileExists(filepath.Join(dir, "metadata.json"))
+}
+
+// versionNumber returns a version's number, or 0 if it is unknown
+func versionNumber(v *client.Version) int {
+	if v == nil {
+		return 0
+	}
+	return v.Number
+}
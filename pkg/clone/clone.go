@@ -1,41 +1,87 @@
 package clone
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
-	"math/rand"
-
 	"github.com/nycmonkey/confluence-reader/pkg/client"
 	"github.com/nycmonkey/confluence-reader/pkg/markdown"
 )
 
 // Cloner handles the cloning of Confluence content
 type Cloner struct {
-	client         *client.Client
-	outputDir      string
-	exportMarkdown bool
-	converter      *markdown.Converter
-	domain         string
-	SampleSpaces   int
-	SamplePages    int
+	client             *client.Client
+	outputDir          string
+	exportMarkdown     bool
+	converter          *markdown.Converter
+	domain             string
+	SampleSpaces       int
+	SamplePages        int
+	SampleStrategy     SampleStrategy // How sampled pages and blog posts are picked
+	SampleSeed         int64          // Seed for random samples; 0 picks one and records it in the sample manifest
+	SampleReplay       string         // Sample manifest whose spaces, pages and blog posts are cloned instead of a new sample
+	ExportComments     bool           // Save footer and inline comments for each page and blog post
+	VersionHistory     int            // Historical versions to save per page and blog post: 0 none, N the last N, AllVersions every one
+	Layout             Layout         // How page directories are arranged under pages/
+	Incremental        bool           // Skip pages and attachments whose version is already on disk
+	RefreshAnnotations bool           // With Incremental, re-check the labels and comments of unchanged content, which change without a new version
+	Deletions          DeletionPolicy // What to do with content deleted upstream
+	Resume             bool           // Skip work an interrupted run already finished
+	Filters            Filters        // Which spaces, pages and blog posts to clone
+
+	AttachmentFilters AttachmentFilters // Which attachments to download
+	BlobStore         BlobStore         // Keep each distinct attachment once under blobs/, linked or referenced from its pages
+
+	IncludePersonalSpaces bool     // Clone personal spaces, which are skipped by default
+	IncludeArchivedSpaces bool     // Clone archived spaces, which are skipped by default
+	IncludeArchivedPages  bool     // Clone archived pages and blog posts, which are skipped by default
+	ContentStatuses       []string // Page and blog post statuses to list (e.g. current, archived, draft, trashed); empty uses the API default of current and archived
+
+	SpaceWorkers      int // Spaces cloned at once
+	PageWorkers       int // Pages and blog posts cloned at once, across all spaces
+	AttachmentWorkers int // Attachments downloaded at once, across all pages
+
+	mu         sync.Mutex      // Protects console output from concurrent workers
+	users      userCache       // Account IDs resolved during this run
+	started    time.Time       // When this run started, used to name tombstones
+	checkpoint *checkpoint     // Journal of this run's progress
+	sampled    *sampleManifest // Sample this run picked, nil when not sampling
+	replay     *sampleManifest // Sample being replayed, if any
+	planning   bool            // Listing for a dry-run plan rather than a clone
+
+	linkWarning sync.Once // Warns once when blobs have to be copied rather than hard-linked
+
+	spacePool      workerPool // Workers shared by this run's spaces
+	pagePool       workerPool // Workers shared by every space's pages and blog posts
+	attachmentPool workerPool // Workers shared by every page's attachment downloads
 }
 
 // NewCloner creates a new Cloner instance
 func NewCloner(c *client.Client, outputDir string, sampleSpaces int, samplePages int) *Cloner {
 	return &Cloner{
-		client:         c,
-		outputDir:      outputDir,
-		exportMarkdown: false,
-		converter:      nil,
-		domain:         "",
-		SampleSpaces:   sampleSpaces,
-		SamplePages:    samplePages,
+		client:            c,
+		outputDir:         outputDir,
+		exportMarkdown:    false,
+		converter:         nil,
+		domain:            "",
+		SampleSpaces:      sampleSpaces,
+		SamplePages:       samplePages,
+		SampleStrategy:    SampleRandom,
+		SpaceWorkers:      1,
+		PageWorkers:       5,
+		AttachmentWorkers: 5,
+		Layout:            LayoutFlat,
+		Deletions:         DeletionReport,
+		BlobStore:         BlobStoreOff,
 	}
 }
 
@@ -48,62 +94,123 @@ func (cl *Cloner) EnableMarkdownExport(domain string) {
 
 // Clone performs the full clone operation
 func (cl *Cloner) Clone() error {
+	return cl.CloneContext(context.Background())
+}
+
+// CloneContext performs the full clone operation, stopping in-flight requests
+// and page workers as soon as ctx is cancelled
+func (cl *Cloner) CloneContext(ctx context.Context) error {
+	cl.started = time.Now()
+
 	// Create output directory
 	if err := os.MkdirAll(cl.outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Get all spaces
-	fmt.Println("Fetching spaces...")
-	spaces, err := cl.client.GetSpaces()
+	// Journal progress so an interrupted run can be resumed
+	var err error
+	cl.checkpoint, err = openCheckpoint(cl.outputDir, cl.Resume)
 	if err != nil {
-		return fmt.Errorf("failed to get spaces: %w", err)
+		cl.logf("Warning: Failed to load checkpoint, starting from scratch: %v\n", err)
+	}
+	if cl.Resume {
+		cl.logf("Resuming: %d space(s), %d page(s), %d blog post(s) and %d attachment(s) already cloned\n",
+			cl.checkpoint.count(checkpointSpace), cl.checkpoint.count(checkpointPage),
+			cl.checkpoint.count(checkpointBlogPost), cl.checkpoint.count(checkpointAttachment))
 	}
+	defer func() {
+		if err := cl.checkpoint.flush(); err != nil {
+			cl.logf("Warning: %v\n", err)
+		}
+	}()
 
-	// Filter out personal and archived spaces
-	filteredSpaces := make([]client.Space, 0, len(spaces))
-	for _, space := range spaces {
-		if space.Type == "personal" {
-			fmt.Printf("Skipping personal space: %s (%s)\n", space.Name, space.Key)
-			continue
+	// Pick the sample seed, or load the sample being replayed
+	if err := cl.startSample(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := cl.sampled.save(cl.outputDir); err != nil {
+			cl.logf("Warning: %v\n", err)
 		}
-		if space.Status == "archived" {
-			fmt.Printf("Skipping archived space: %s (%s)\n", space.Name, space.Key)
-			continue
+	}()
+
+	// Pick the spaces to clone
+	spaces, err := cl.selectSpaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Clone spaces concurrently. Rejected credentials would fail every other
+	// space too, so they stop the whole run.
+	cl.startWorkers()
+	spaceCtx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	err = cl.forEachConcurrent(spaceCtx, cl.spacePool, len(spaces), func(i int) {
+		space := spaces[i]
+		if cl.Resume && cl.checkpoint.done(checkpointSpace, space.ID, 0) {
+			cl.logf("[%d/%d] Skipping space already cloned: %s (%s)\n", i+1, len(spaces), space.Name, space.Key)
+			return
 		}
-		filteredSpaces = append(filteredSpaces, space)
+
+		cl.logf("[%d/%d] Processing space: %s (%s)\n", i+1, len(spaces), space.Name, space.Key)
+		failures := cl.checkpoint.failures()
+		if err := cl.cloneSpace(spaceCtx, space); err != nil {
+			if spaceCtx.Err() != nil {
+				return
+			}
+			cl.checkpoint.fail(checkpointSpace, space.ID, err)
+			switch {
+			case client.IsUnauthorized(err):
+				stop(fmt.Errorf("authentication failed while cloning space %s: %w", space.Key, err))
+			case client.IsForbidden(err):
+				cl.logf("  Warning: No permission on space %s, skipping\n", space.Key)
+			default:
+				cl.logf("  Warning: Failed to clone space %s: %v\n", space.Key, err)
+			}
+			return
+		}
+
+		// A space is finished once nothing failed while it was cloned. A failure
+		// in a space cloned alongside it keeps it open too, which only costs a
+		// fresh listing on resume: its finished pages are still skipped.
+		if cl.checkpoint.failures() == failures {
+			cl.checkpoint.complete(checkpointSpace, space.ID, 0)
+		}
+		if err := cl.sampled.save(cl.outputDir); err != nil {
+			cl.logf("  Warning: %v\n", err)
+		}
+	})
+	if err != nil {
+		return context.Cause(spaceCtx)
 	}
-	spaces = filteredSpaces
 
-	// Sample spaces if configured
-	if cl.SampleSpaces > 0 && len(spaces) > cl.SampleSpaces {
-		fmt.Printf("Sampling %d of %d spaces...\n", cl.SampleSpaces, len(spaces))
-		r := rand.New(rand.NewSource(time.Now().UnixNano()))
-		r.Shuffle(len(spaces), func(i, j int) {
-			spaces[i], spaces[j] = spaces[j], spaces[i]
-		})
-		spaces = spaces[:cl.SampleSpaces]
-	}
-
-	fmt.Printf("Found %d space(s) to clone\n", len(spaces))
-	fmt.Println()
-
-	// Clone each space
-	for i, space := range spaces {
-		fmt.Printf("[%d/%d] Processing space: %s (%s)\n", i+1, len(spaces), space.Name, space.Key)
-		if err := cl.cloneSpace(space); err != nil {
-			fmt.Printf("  Warning: Failed to clone space %s: %v\n", space.Key, err)
-			continue
+	// Drop blobs no attachment uses any more, including those left behind
+	// by switching the blob store off
+	if cl.blobStoreEnabled() || fileExists(filepath.Join(cl.outputDir, blobDirName)) {
+		removed, freed, err := cl.CollectBlobs()
+		if err != nil {
+			cl.logf("Warning: Failed to clean up the blob store: %v\n", err)
+		} else if removed > 0 {
+			cl.logf("Removed %d unreferenced blob(s), freeing %s\n", removed, formatBytes(freed))
 		}
 	}
 
+	// Keep the journal only if there are failures left to retry
+	if n := cl.checkpoint.failures(); n > 0 {
+		cl.logf("\n%d item(s) failed; run again with resume enabled to retry them\n", n)
+		return nil
+	}
+	if err := cl.checkpoint.remove(); err != nil {
+		cl.logf("Warning: Failed to remove checkpoint: %v\n", err)
+	}
+
 	return nil
 }
 
 // cloneSpace clones a single space
-func (cl *Cloner) cloneSpace(space client.Space) error {
+func (cl *Cloner) cloneSpace(ctx context.Context, space client.Space) error {
 	// Create space directory
-	spaceDir := filepath.Join(cl.outputDir, sanitizeFilename(space.Key))
+	spaceDir := filepath.Join(cl.outputDir, spaceDirName(space.Key))
 	if err := os.MkdirAll(spaceDir, 0755); err != nil {
 		return fmt.Errorf("failed to create space directory: %w", err)
 	}
@@ -119,243 +226,452 @@ func (cl *Cloner) cloneSpace(space client.Space) error {
 	if space.Description != nil && space.Description.Plain != nil {
 		spaceMetadata["description"] = space.Description.Plain.Value
 	}
+	if labels, err := cl.client.GetSpaceLabelsContext(ctx, space.ID); err != nil {
+		cl.logf("  Warning: Failed to get space labels: %v\n", err)
+	} else {
+		spaceMetadata["labels"] = client.LabelNames(labels)
+	}
 
 	metadataPath := filepath.Join(spaceDir, "space.json")
 	if err := saveJSON(metadataPath, spaceMetadata); err != nil {
 		return fmt.Errorf("failed to save space metadata: %w", err)
 	}
 
-	// Get all pages in space
-	fmt.Printf("  Fetching pages...\n")
-	pages, err := cl.client.GetSpacePages(space.ID)
+	// Pick the pages to clone
+	pages, tree, err := cl.selectPages(ctx, space)
 	if err != nil {
-		return fmt.Errorf("failed to get pages: %w", err)
+		return err
 	}
 
-	// Sample pages if configured
-	if cl.SamplePages > 0 && len(pages) > cl.SamplePages {
-		fmt.Printf("  Sampling %d of %d pages...\n", cl.SamplePages, len(pages))
-		r := rand.New(rand.NewSource(time.Now().UnixNano()))
-		r.Shuffle(len(pages), func(i, j int) {
-			pages[i], pages[j] = pages[j], pages[i]
-		})
-		pages = pages[:cl.SamplePages]
+	// Record the page hierarchy at the space root
+	treePath := filepath.Join(spaceDir, "tree.json")
+	if err := saveJSON(treePath, map[string]interface{}{
+		"spaceId":  space.ID,
+		"spaceKey": space.Key,
+		"pages":    tree.Roots,
+	}); err != nil {
+		return fmt.Errorf("failed to save page tree: %w", err)
 	}
 
-	fmt.Printf("  Found %d page(s)\n", len(pages))
-
 	// Create pages directory
 	pagesDir := filepath.Join(spaceDir, "pages")
 	if err := os.MkdirAll(pagesDir, 0755); err != nil {
 		return fmt.Errorf("failed to create pages directory: %w", err)
 	}
 
+	// Move pages already on disk to where the layout now puts them
+	if err := cl.relocatePages(tree, pagesDir); err != nil {
+		cl.logf("  Warning: Failed to relocate pages: %v\n", err)
+	}
+
+	// Load what earlier runs saved, and record this run's progress even if it's interrupted
+	state, err := loadSpaceState(spaceDir)
+	if err != nil {
+		cl.logf("  Warning: Failed to load sync state, cloning everything: %v\n", err)
+	}
+	defer func() {
+		if err := state.save(spaceDir); err != nil {
+			cl.logf("  Warning: Failed to save sync state: %v\n", err)
+		}
+	}()
+
 	// Clone each page concurrently with limited concurrency
-	const maxConcurrent = 5
-	semaphore := make(chan struct{}, maxConcurrent)
-	var wg sync.WaitGroup
-	var mu sync.Mutex // Protect console output
+	err = cl.forEachConcurrent(ctx, cl.pagePool, len(pages), func(j int) {
+		p := pages[j]
+		cl.logf("  [%d/%d] Cloning page: %s\n", j+1, len(pages), p.Title)
+
+		if err := cl.clonePage(ctx, p, spaceDir, space.Key, tree, state); err != nil && ctx.Err() == nil {
+			cl.logContentError("page", p.Title, err)
+			cl.checkpoint.fail(checkpointPage, p.ID, err)
+		}
+		// The body has been written; don't hold every page's body until the space is done
+		pages[j].Body = nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Deal with pages deleted, moved out of the space or renamed upstream.
+	// A listing restricted to other statuses can't tell what was deleted.
+	if cl.completeListing() {
+		if stale, err := stalePageDirs(pagesDir, tree); err != nil {
+			cl.logf("  Warning: Failed to check for deleted pages: %v\n", err)
+		} else {
+			cl.handleStale(pagesDir, stale, "page")
+		}
+		state.prune(state.Pages, tree.ids())
+	}
+
+	// Blog posts live alongside pages in the space
+	return cl.cloneBlogPosts(ctx, space, spaceDir, state)
+}
+
+// selectSpaces lists the spaces to clone, after the status policies, filters and sampling
+func (cl *Cloner) selectSpaces(ctx context.Context) ([]client.Space, error) {
+	// Get all spaces, letting the API apply the space filters it supports
+	cl.logf("Fetching spaces...\n")
+	spaceQuery := cl.Filters.spaceQuery()
+	if !cl.IncludeArchivedSpaces {
+		spaceQuery.Set("status", "current")
+	}
+	spaces, err := client.Collect(cl.client.Spaces(ctx, &client.ListOptions{Query: spaceQuery}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spaces: %w", err)
+	}
 
+	// Filter out personal and archived spaces unless asked for, and spaces excluded by the filters
+	filteredSpaces := make([]client.Space, 0, len(spaces))
+	excluded := 0
+	for _, space := range spaces {
+		if space.Type == "personal" && !cl.includePersonalSpaces() {
+			cl.logf("Skipping personal space: %s (%s)\n", space.Name, space.Key)
+			continue
+		}
+		if space.Status == "archived" && !cl.IncludeArchivedSpaces {
+			cl.logf("Skipping archived space: %s (%s)\n", space.Name, space.Key)
+			continue
+		}
+		if !cl.Filters.matchSpace(space) {
+			excluded++
+			continue
+		}
+		filteredSpaces = append(filteredSpaces, space)
+	}
+	spaces = filteredSpaces
+	if excluded > 0 {
+		cl.logf("Excluded %d space(s) by filters\n", excluded)
+	}
+
+	// Sample spaces if configured
+	spaces = cl.sampleSpaces(spaces)
+
+	cl.logf("Found %d space(s) to clone\n", len(spaces))
+	cl.logf("\n")
+	return spaces, nil
+}
+
+// selectPages lists a space's pages and picks the ones to clone, after the
+// filters, sampling and archived policy. It also returns the hierarchy of
+// every page in the space.
+func (cl *Cloner) selectPages(ctx context.Context, space client.Space) ([]client.Page, *pageTree, error) {
+	// Get all pages in space
+	cl.logf("  Fetching pages...\n")
+	listOpts := &client.ListOptions{Query: cl.contentQuery()}
+	if cl.listSpaceBodies(space) {
+		// Bodies arrive with the listing, saving a request per page
+		listOpts.BodyFormat = "storage"
+	}
+	pages, err := client.Collect(cl.client.SpacePages(ctx, space.ID, listOpts))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get pages: %w", err)
+	}
+
+	// Build the page hierarchy from the full listing, so layout and deletion
+	// detection see every page even when only some are cloned
+	tree := buildPageTree(pages, cl.Layout)
+
+	// Apply page filters before sampling
+	selected := make([]client.Page, 0, len(pages))
+	for _, page := range pages {
+		if cl.Filters.matchContent(page.Title, page.Version) && cl.Filters.inSubtree(tree, page.ID) {
+			selected = append(selected, page)
+		}
+	}
+	if excluded := len(pages) - len(selected); excluded > 0 {
+		cl.logf("  Excluded %d of %d page(s) by filters\n", excluded, len(pages))
+	}
+	pages = selected
+
+	// Sample pages if configured
+	pages = cl.samplePages(space, pages, tree)
+
+	cl.logf("  Found %d page(s)\n", len(pages))
+
+	// Skip archived pages unless asked for
+	current := make([]client.Page, 0, len(pages))
 	for j, page := range pages {
-		// Skip archived pages
-		if page.Status == "archived" {
-			mu.Lock()
-			fmt.Printf("  [%d/%d] Skipping archived page: %s\n", j+1, len(pages), page.Title)
-			mu.Unlock()
+		if page.Status == "archived" && !cl.includeArchivedContent() {
+			cl.logf("  [%d/%d] Skipping archived page: %s\n", j+1, len(pages), page.Title)
 			continue
 		}
+		current = append(current, page)
+	}
+	return current, tree, nil
+}
 
-		wg.Add(1)
+// workerPool bounds how many tasks of one kind run at once. A pool is shared
+// by every caller, so pages from all spaces being cloned compete for the same
+// workers and a large space can't starve a small one.
+type workerPool chan struct{}
 
-		go func(index int, p client.Page, spaceKey string) {
-			defer wg.Done()
+// newWorkerPool creates a pool of n workers, at least one
+func newWorkerPool(n int) workerPool {
+	return make(workerPool, max(n, 1))
+}
 
-			// Acquire semaphore
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
+// startWorkers creates the space, page and attachment pools for a run
+func (cl *Cloner) startWorkers() {
+	cl.spacePool = newWorkerPool(cl.SpaceWorkers)
+	cl.pagePool = newWorkerPool(cl.PageWorkers)
+	cl.attachmentPool = newWorkerPool(cl.AttachmentWorkers)
+}
 
-			mu.Lock()
-			fmt.Printf("  [%d/%d] Cloning page: %s\n", index+1, len(pages), p.Title)
-			mu.Unlock()
+// forEachConcurrent calls fn for every index in [0, n), running as many at
+// once as pool has free workers; a nil pool runs them one at a time. It stops
+// starting new calls once ctx is cancelled and waits for running ones.
+func (cl *Cloner) forEachConcurrent(ctx context.Context, pool workerPool, n int, fn func(i int)) error {
+	if pool == nil {
+		pool = newWorkerPool(1)
+	}
+	var wg sync.WaitGroup
 
-			if err := cl.clonePage(p, pagesDir, spaceKey); err != nil {
-				mu.Lock()
-				fmt.Printf("    Warning: Failed to clone page %s: %v\n", p.Title, err)
-				mu.Unlock()
-			}
-		}(j, page, space.Key)
+	for i := 0; i < n; i++ {
+		// Acquire a worker before spawning so cancellation stops new work
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case pool <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-pool }()
+			fn(index)
+		}(i)
 	}
 
 	wg.Wait()
-	return nil
+	return ctx.Err()
 }
 
-// clonePage clones a single page
-func (cl *Cloner) clonePage(page client.Page, pagesDir string, spaceKey string) error {
-	// Get full page content
-	fullPage, err := cl.client.GetPage(page.ID)
-	if err != nil {
-		return fmt.Errorf("failed to get full page content: %w", err)
-	}
+// logf prints a progress line without interleaving output from concurrent workers
+func (cl *Cloner) logf(format string, args ...interface{}) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	fmt.Printf(format, args...)
+}
 
-	// Create page directory
-	pageDirName := fmt.Sprintf("%s_%s", page.ID, sanitizeFilename(page.Title))
-	pageDir := filepath.Join(pagesDir, pageDirName)
-	if err := os.MkdirAll(pageDir, 0755); err != nil {
-		return fmt.Errorf("failed to create page directory: %w", err)
+// logContentError reports a failed page or blog post, calling out common API failures
+func (cl *Cloner) logContentError(kind, title string, err error) {
+	switch {
+	case client.IsNotFound(err):
+		cl.logf("    Warning: %s %s no longer exists, skipping\n", capitalize(kind), title)
+	case client.IsForbidden(err):
+		cl.logf("    Warning: No permission on %s %s, skipping\n", kind, title)
+	default:
+		cl.logf("    Warning: Failed to clone %s %s: %v\n", kind, title, err)
 	}
+}
 
-	// Save page metadata
-	pageMetadata := map[string]interface{}{
-		"id":       fullPage.ID,
-		"title":    fullPage.Title,
-		"status":   fullPage.Status,
-		"spaceId":  fullPage.SpaceID,
-		"parentId": fullPage.ParentID,
+// capitalize upper-cases the first letter of an ASCII word
+func capitalize(s string) string {
+	if s == "" {
+		return s
 	}
-	if fullPage.Version != nil {
-		pageMetadata["version"] = map[string]interface{}{
-			"number":    fullPage.Version.Number,
-			"createdAt": fullPage.Version.When,
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// clonePage clones a single page
+func (cl *Cloner) clonePage(ctx context.Context, page client.Page, spaceDir string, spaceKey string, tree *pageTree, state *spaceState) error {
+	return cl.cloneContent(ctx, pageContent{page}, contentTarget{
+		spaceDir: spaceDir,
+		spaceKey: spaceKey,
+		relDir:   filepath.Join("pages", tree.dir(page.ID, page.Title)),
+		state:    state,
+		items:    state.Pages,
+		children: tree.childLinks(page.ID),
+	})
+}
+
+// saveAttachments downloads attachments into an attachments/ directory under dir.
+// It returns the state of every attachment now on disk or left out by the
+// attachment filters; in incremental mode, attachments already recorded in prev
+// at the same version are not downloaded again.
+func (cl *Cloner) saveAttachments(ctx context.Context, attachments []client.Attachment, dir string, prev map[string]attachmentState) (map[string]attachmentState, error) {
+	saved := make(map[string]attachmentState, len(attachments))
+	attachmentsDir := filepath.Join(dir, "attachments")
+	if len(attachments) == 0 {
+		cl.pruneAttachments(attachmentsDir, attachments)
+		if err := recordSkippedAttachments(dir, nil); err != nil && !os.IsNotExist(err) {
+			cl.logf("    Warning: Failed to record skipped attachments: %v\n", err)
 		}
+		return saved, nil
 	}
 
-	metadataPath := filepath.Join(pageDir, "metadata.json")
-	if err := saveJSON(metadataPath, pageMetadata); err != nil {
-		return fmt.Errorf("failed to save page metadata: %w", err)
+	cl.logf("    Found %d attachment(s)\n", len(attachments))
+	if err := os.MkdirAll(attachmentsDir, 0755); err != nil {
+		return saved, fmt.Errorf("failed to create attachments directory: %w", err)
 	}
 
-	// Save page content (storage format)
-	if fullPage.Body != nil && fullPage.Body.Storage != nil {
-		contentPath := filepath.Join(pageDir, "content.html")
-		if err := os.WriteFile(contentPath, []byte(fullPage.Body.Storage.Value), 0644); err != nil {
-			return fmt.Errorf("failed to save page content: %w", err)
-		}
+	// Download concurrently, sharing the attachment workers with every other page
+	var mu sync.Mutex
+	unchanged := 0
+	var skipped []skippedAttachment
+	files := attachmentFileNames(attachments)
+	err := cl.forEachConcurrent(ctx, cl.attachmentPool, len(attachments), func(k int) {
+		attachment := attachments[k]
+		current := newAttachmentState(attachment, files[attachment.ID])
 
-		// Export markdown if enabled
-		if cl.exportMarkdown {
-			md, err := cl.convertPageToMarkdown(*fullPage, spaceKey)
-			if err != nil {
-				fmt.Printf("    Warning: Failed to convert to markdown: %v\n", err)
-			} else {
-				mdPath := filepath.Join(pageDir, "content.md")
-				if err := os.WriteFile(mdPath, []byte(md), 0644); err != nil {
-					fmt.Printf("    Warning: Failed to save markdown: %v\n", err)
+		// Attachments the filters leave out are only noted in the page metadata
+		if reason := cl.AttachmentFilters.skipReason(attachment); reason != "" {
+			if cl.AttachmentFilters.MetadataOnly {
+				if err := saveAttachmentSidecar(attachmentsDir, attachment, current.File, ""); err != nil {
+					cl.logf("      Warning: Failed to save attachment metadata: %v\n", err)
+					return
 				}
 			}
+			mu.Lock()
+			saved[attachment.ID] = current
+			skipped = append(skipped, skippedAttachment{
+				ID:        attachment.ID,
+				Title:     attachment.Title,
+				MediaType: attachment.MediaType,
+				FileSize:  attachment.FileSize,
+				Reason:    reason,
+			})
+			mu.Unlock()
+			return
 		}
-	}
-
-	// Get and save attachments
-	attachments, err := cl.client.GetPageAttachments(page.ID)
-	if err != nil {
-		fmt.Printf("    Warning: Failed to get attachments: %v\n", err)
-	} else if len(attachments) > 0 {
-		fmt.Printf("    Found %d attachment(s)\n", len(attachments))
-		attachmentsDir := filepath.Join(pageDir, "attachments")
-		if err := os.MkdirAll(attachmentsDir, 0755); err != nil {
-			return fmt.Errorf("failed to create attachments directory: %w", err)
+		p, synced := prev[attachment.ID]
+		skip := (cl.Incremental && synced && p == current) ||
+			(cl.Resume && cl.checkpoint.done(checkpointAttachment, attachment.ID, current.Version))
+		if skip && cl.attachmentOnDisk(attachmentsDir, attachment, current.File) {
+			mu.Lock()
+			saved[attachment.ID] = current
+			unchanged++
+			mu.Unlock()
+			return
 		}
 
-		for k, attachment := range attachments {
-			fmt.Printf("    [%d/%d] Downloading: %s\n", k+1, len(attachments), attachment.Title)
-			if err := cl.downloadAttachment(attachment, attachmentsDir); err != nil {
-				fmt.Printf("      Warning: Failed to download attachment %s: %v\n", attachment.Title, err)
-				continue
+		cl.logf("    [%d/%d] Downloading: %s\n", k+1, len(attachments), attachment.Title)
+		if err := cl.downloadAttachment(ctx, attachment, attachmentsDir, current.File); err != nil {
+			if ctx.Err() == nil {
+				cl.logf("      Warning: Failed to download attachment %s: %v\n", attachment.Title, err)
+				cl.checkpoint.fail(checkpointAttachment, attachment.ID, err)
 			}
+			return
 		}
+		mu.Lock()
+		saved[attachment.ID] = current
+		mu.Unlock()
+		cl.checkpoint.complete(checkpointAttachment, attachment.ID, current.Version)
+	})
+	if err != nil {
+		return saved, err
+	}
+	if unchanged > 0 {
+		cl.logf("    Skipped %d attachment(s) already on disk\n", unchanged)
+	}
+	if len(skipped) > 0 {
+		cl.logf("    Skipped %d attachment(s) by attachment filters\n", len(skipped))
+	}
+	slices.SortFunc(skipped, func(a, b skippedAttachment) int { return compareIDs(a.ID, b.ID) })
+	if err := recordSkippedAttachments(dir, skipped); err != nil && !os.IsNotExist(err) {
+		cl.logf("    Warning: Failed to record skipped attachments: %v\n", err)
 	}
 
-	return nil
+	// Deal with attachments deleted or renamed upstream
+	cl.pruneAttachments(attachmentsDir, attachments)
+
+	return saved, nil
 }
 
-// downloadAttachment downloads and saves an attachment
-func (cl *Cloner) downloadAttachment(attachment client.Attachment, attachmentsDir string) error {
+// attachmentOnDisk reports whether an attachment saved by an earlier run is
+// still in the output
+func (cl *Cloner) attachmentOnDisk(attachmentsDir string, attachment client.Attachment, file string) bool {
+	if cl.blobStoreEnabled() {
+		return cl.storedAttachment(attachmentsDir, attachment, file)
+	}
+	return cl.unstoredAttachment(attachmentsDir, attachment, file)
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// downloadAttachment downloads an attachment and saves it as file in attachmentsDir
+func (cl *Cloner) downloadAttachment(ctx context.Context, attachment client.Attachment, attachmentsDir, file string) error {
 	if attachment.DownloadURL == "" {
 		return fmt.Errorf("no download URL available (ID: %s, Title: %s)", attachment.ID, attachment.Title)
 	}
 
-	data, err := cl.client.DownloadAttachment(attachment.DownloadURL)
+	body, err := cl.client.OpenAttachmentContext(ctx, attachment.DownloadURL)
 	if err != nil {
 		return fmt.Errorf("download failed for URL '%s': %w", attachment.DownloadURL, err)
 	}
+	defer body.Close()
 
-	filename := sanitizeFilename(attachment.Title)
-	filePath := filepath.Join(attachmentsDir, filename)
+	filePath := filepath.Join(attachmentsDir, file)
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	// Stream straight to disk so large attachments never sit in memory
+	var hash string
+	if cl.blobStoreEnabled() {
+		if hash, err = cl.storeBlob(body, attachment.FileSize); err != nil {
+			return fmt.Errorf("failed to store attachment: %w", err)
+		}
+		if err := cl.placeBlob(hash, filePath); err != nil {
+			return fmt.Errorf("failed to place attachment from the blob store: %w", err)
+		}
+	} else if _, err := writeStreamAtomic(filePath, body, attachment.FileSize); err != nil {
 		return fmt.Errorf("failed to save attachment: %w", err)
 	}
 
 	// Save attachment metadata
-	metadataPath := filepath.Join(attachmentsDir, filename+".json")
-	metadata := map[string]interface{}{
-		"id":        attachment.ID,
-		"title":     attachment.Title,
-		"type":      attachment.Type,
-		"mediaType": attachment.MediaType,
-		"fileSize":  attachment.FileSize,
-	}
-	if err := saveJSON(metadataPath, metadata); err != nil {
-		fmt.Printf("      Warning: Failed to save attachment metadata: %v\n", err)
+	if err := saveAttachmentSidecar(attachmentsDir, attachment, file, hash); err != nil {
+		cl.logf("      Warning: Failed to save attachment metadata: %v\n", err)
 	}
 
 	return nil
 }
 
-// sanitizeFilename removes invalid characters from filenames
-func sanitizeFilename(name string) string {
-	// Replace invalid filename characters
-	invalid := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|"}
-	result := name
-	for _, char := range invalid {
-		result = strings.ReplaceAll(result, char, "_")
-	}
-	// Limit length
-	if len(result) > 200 {
-		result = result[:200]
-	}
-	return strings.TrimSpace(result)
-}
-
 // saveJSON saves data as JSON to a file
 func saveJSON(filepath string, data interface{}) error {
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath, jsonData, 0644)
+	return writeFileAtomic(filepath, jsonData)
 }
 
-// convertPageToMarkdown converts a page to markdown with frontmatter
-func (cl *Cloner) convertPageToMarkdown(page client.Page, spaceKey string) (string, error) {
-	if page.Body == nil || page.Body.Storage == nil {
-		return "", fmt.Errorf("page has no content")
-	}
+// writeFileAtomic writes data to a temporary file and renames it into place,
+// so an interrupted run never leaves a half-written file behind
+func writeFileAtomic(path string, data []byte) error {
+	_, err := writeStreamAtomic(path, bytes.NewReader(data), -1)
+	return err
+}
 
-	// Extract metadata
-	var versionNumber int
-	var author string
-	if page.Version != nil {
-		versionNumber = page.Version.Number
+// writeStreamAtomic copies r to a temporary file next to path and renames it
+// into place. If expectedSize is positive, the number of bytes written must
+// match it or the file is discarded.
+func writeStreamAtomic(path string, r io.Reader, expectedSize int64) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return 0, err
 	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
 
-	// Build page URL
-	pageURL := ""
-	if cl.domain != "" {
-		pageURL = fmt.Sprintf("https://%s/wiki/spaces/%s/pages/%s", cl.domain, spaceKey, page.ID)
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return n, err
 	}
-
-	meta := markdown.PageMetadata{
-		Title:    page.Title,
-		PageID:   page.ID,
-		SpaceKey: spaceKey,
-		Version:  versionNumber,
-		Author:   author,
-		ParentID: page.ParentID,
-		URL:      pageURL,
+	if expectedSize > 0 && n != expectedSize {
+		tmp.Close()
+		return n, fmt.Errorf("size mismatch: expected %d bytes, got %d", expectedSize, n)
 	}
-
-	// Convert with metadata
-	return cl.converter.ConvertWithMetadata(page.Body.Storage.Value, meta)
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return n, err
+	}
+	if err := tmp.Close(); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp.Name(), path)
 }
@@ -1,7 +1,19 @@
 package clone
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
 )
 
 func TestSanitizeFilename(t *testing.T) {
@@ -60,3 +72,1029 @@ func TestSanitizeFilenameLongName(t *testing.T) {
 		t.Errorf("sanitizeFilename did not truncate long filename, got length %d", len(result))
 	}
 }
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "content.html")
+
+	if err := writeFileAtomic(path, []byte("first")); err != nil {
+		t.Fatalf("writeFileAtomic failed: %v", err)
+	}
+	if err := writeFileAtomic(path, []byte("second")); err != nil {
+		t.Fatalf("writeFileAtomic overwrite failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != "second" {
+		t.Errorf("Expected content 'second', got %q", string(data))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the target file to remain, found %d entries", len(entries))
+	}
+}
+
+func TestWriteStreamAtomicSizeMismatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "attachment.bin")
+
+	n, err := writeStreamAtomic(path, strings.NewReader("12345"), 5)
+	if err != nil {
+		t.Fatalf("writeStreamAtomic failed: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("Expected 5 bytes written, got %d", n)
+	}
+
+	if _, err := writeStreamAtomic(filepath.Join(dir, "short.bin"), strings.NewReader("123"), 5); err == nil {
+		t.Error("Expected size mismatch error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "short.bin")); !os.IsNotExist(err) {
+		t.Error("Expected truncated download to be discarded")
+	}
+}
+
+func TestBlogPostDirName(t *testing.T) {
+	tests := []struct {
+		post     client.BlogPost
+		expected string
+	}{
+		{
+			post:     client.BlogPost{ID: "77", Title: "Q3: Team Update", CreatedAt: "2025-03-14T09:30:00.000Z"},
+			expected: "2025-03-14_77_Q3_ Team Update",
+		},
+		{
+			post:     client.BlogPost{ID: "78", Title: "Draft"},
+			expected: "undated_78_Draft",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := blogPostDirName(tt.post); got != tt.expected {
+				t.Errorf("blogPostDirName() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildPageTree(t *testing.T) {
+	pages := []client.Page{
+		{ID: "3", Title: "Second", ParentID: "1", Position: 2},
+		{ID: "2", Title: "First", ParentID: "1", Position: 1},
+		{ID: "1", Title: "Home"},
+		{ID: "4", Title: "Orphan", ParentID: "99"},
+		// 5 and 6 point at each other
+		{ID: "5", Title: "Loop A", ParentID: "6"},
+		{ID: "6", Title: "Loop B", ParentID: "5"},
+	}
+
+	tree := buildPageTree(pages, LayoutFlat)
+
+	var roots []string
+	for _, root := range tree.Roots {
+		roots = append(roots, root.ID)
+	}
+	if fmt.Sprint(roots) != "[1 6 4]" {
+		t.Errorf("Expected roots [1 6 4], got %v", roots)
+	}
+
+	home := tree.nodes["1"]
+	if len(home.Children) != 2 || home.Children[0].ID != "2" || home.Children[1].ID != "3" {
+		t.Errorf("Expected children ordered by position, got %+v", home.Children)
+	}
+
+	if links := tree.childLinks("1"); len(links) != 2 || links[0].Path != "../2_First/content.md" {
+		t.Errorf("Unexpected child links: %+v", links)
+	}
+	if links := tree.childLinks("2"); links == nil || len(links) != 0 {
+		t.Errorf("Expected empty non-nil links for a leaf page, got %#v", links)
+	}
+}
+
+func TestRelocatePagesNested(t *testing.T) {
+	pagesDir := t.TempDir()
+
+	// A flat clone where page 3 has since been renamed
+	for _, dir := range []string{"1_Home", "2_Guide/attachments", "3_Old Title"} {
+		if err := os.MkdirAll(filepath.Join(pagesDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pages := []client.Page{
+		{ID: "1", Title: "Home"},
+		{ID: "2", Title: "Guide", ParentID: "1"},
+		{ID: "3", Title: "New Title", ParentID: "2"},
+	}
+	tree := buildPageTree(pages, LayoutNested)
+
+	cl := &Cloner{}
+	if err := cl.relocatePages(tree, pagesDir); err != nil {
+		t.Fatalf("relocatePages failed: %v", err)
+	}
+
+	for _, dir := range []string{"1_Home", "1_Home/2_Guide/attachments", "1_Home/2_Guide/3_New Title"} {
+		if _, err := os.Stat(filepath.Join(pagesDir, dir)); err != nil {
+			t.Errorf("Expected %s after relocation: %v", dir, err)
+		}
+	}
+	for _, dir := range []string{"2_Guide", "3_Old Title"} {
+		if _, err := os.Stat(filepath.Join(pagesDir, dir)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to have been moved", dir)
+		}
+	}
+
+	links := tree.childLinks("1")
+	if len(links) != 1 || links[0].Path != "2_Guide/content.md" || links[0].Children[0].Path != "2_Guide/3_New Title/content.md" {
+		t.Errorf("Unexpected nested child links: %+v", links)
+	}
+}
+
+func TestRelocatePagesContinuesAfterFailure(t *testing.T) {
+	pagesDir := t.TempDir()
+	for _, dir := range []string{"2_Old", "3_Old"} {
+		if err := os.MkdirAll(filepath.Join(pagesDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A stray file is in the way of page 2's new directory
+	if err := os.WriteFile(filepath.Join(pagesDir, "2_Second"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := buildPageTree([]client.Page{{ID: "2", Title: "Second"}, {ID: "3", Title: "Third"}}, LayoutFlat)
+	cl := &Cloner{}
+	if err := cl.relocatePages(tree, pagesDir); err != nil {
+		t.Fatalf("relocatePages failed: %v", err)
+	}
+
+	if !fileExists(filepath.Join(pagesDir, "2_Old")) {
+		t.Error("Expected page 2 to stay where it was")
+	}
+	if !fileExists(filepath.Join(pagesDir, "3_Third")) || fileExists(filepath.Join(pagesDir, "3_Old")) {
+		t.Error("Expected page 3 to be moved despite page 2 failing")
+	}
+}
+
+func TestSpaceStateRoundTrip(t *testing.T) {
+	spaceDir := t.TempDir()
+
+	state, err := loadSpaceState(spaceDir)
+	if err != nil {
+		t.Fatalf("loadSpaceState on empty dir failed: %v", err)
+	}
+	state.set(state.Pages, "1", contentState{
+		Title:       "Home",
+		Version:     3,
+		Dir:         "pages/1_Home",
+		Attachments: map[string]attachmentState{"att1": {Version: 2, FileSize: 5, File: "a.txt"}},
+	})
+	if err := state.save(spaceDir); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	loaded, err := loadSpaceState(spaceDir)
+	if err != nil {
+		t.Fatalf("loadSpaceState failed: %v", err)
+	}
+	page := loaded.get(loaded.Pages, "1")
+	if page == nil || page.Version != 3 || page.Attachments["att1"].File != "a.txt" {
+		t.Errorf("Unexpected page state after reload: %+v", page)
+	}
+	if loaded.BlogPosts == nil {
+		t.Error("Expected blog post map to be initialized")
+	}
+}
+
+func TestIncrementalSkipsUnchanged(t *testing.T) {
+	pageDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(pageDir, "metadata.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(pageDir, "attachments"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pageDir, "attachments", "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// No client: any attempt to download would panic
+	cl := &Cloner{Incremental: true}
+	prev := &contentState{Version: 3}
+
+	if !cl.unchanged(prev, &client.Version{Number: 3}, pageDir) {
+		t.Error("Expected same version on disk to be unchanged")
+	}
+	if cl.unchanged(prev, &client.Version{Number: 4}, pageDir) {
+		t.Error("Expected newer version to be changed")
+	}
+	if cl.unchanged(prev, &client.Version{Number: 3}, t.TempDir()) {
+		t.Error("Expected missing page directory to be changed")
+	}
+
+	attachment := client.Attachment{ID: "att1", Title: "a.txt", FileSize: 5, Version: &client.Version{Number: 2}}
+	saved, err := cl.saveAttachments(context.Background(), []client.Attachment{attachment}, pageDir,
+		map[string]attachmentState{"att1": {Version: 2, FileSize: 5, File: "a.txt"}})
+	if err != nil {
+		t.Fatalf("saveAttachments failed: %v", err)
+	}
+	if saved["att1"].Version != 2 {
+		t.Errorf("Expected unchanged attachment to be kept in state, got %+v", saved)
+	}
+
+	cl.Incremental = false
+	if cl.unchanged(prev, &client.Version{Number: 3}, pageDir) {
+		t.Error("Expected full clones to never skip pages")
+	}
+}
+
+func TestDeletedContent(t *testing.T) {
+	outputDir := t.TempDir()
+	pagesDir := filepath.Join(outputDir, "DOC", "pages")
+
+	// Page 2 was deleted upstream and page 3 left a directory under its old title
+	for _, dir := range []string{"1_Home/attachments", "2_Gone", "3_Old Title", "3_New Title"} {
+		if err := os.MkdirAll(filepath.Join(pagesDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"kept.png", "kept.png.json", "removed.png", "removed.png.json", ".tmp-123"} {
+		if err := os.WriteFile(filepath.Join(pagesDir, "1_Home", "attachments", file), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tree := buildPageTree([]client.Page{{ID: "1", Title: "Home"}, {ID: "3", Title: "New Title"}}, LayoutFlat)
+	stale, err := stalePageDirs(pagesDir, tree)
+	if err != nil {
+		t.Fatalf("stalePageDirs failed: %v", err)
+	}
+	if fmt.Sprint(stale) != "[2_Gone 3_Old Title]" {
+		t.Errorf("Expected stale [2_Gone 3_Old Title], got %v", stale)
+	}
+
+	attachmentsDir := filepath.Join(pagesDir, "1_Home", "attachments")
+	staleFiles, err := staleAttachmentFiles(attachmentsDir, []client.Attachment{{ID: "a1", Title: "kept.png"}})
+	if err != nil {
+		t.Fatalf("staleAttachmentFiles failed: %v", err)
+	}
+	if fmt.Sprint(staleFiles) != "[removed.png removed.png.json]" {
+		t.Errorf("Expected stale attachment files, got %v", staleFiles)
+	}
+
+	// Report leaves everything in place
+	cl := &Cloner{outputDir: outputDir, Deletions: DeletionReport}
+	if cl.handleStale(pagesDir, stale, "page") {
+		t.Error("Expected report mode not to remove anything")
+	}
+	if _, err := os.Stat(filepath.Join(pagesDir, "2_Gone")); err != nil {
+		t.Errorf("Expected report mode to keep stale page: %v", err)
+	}
+
+	// Tombstone moves stale pages under _deleted/<timestamp>/
+	cl.Deletions = DeletionTombstone
+	cl.started = time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+	if !cl.handleStale(pagesDir, stale, "page") {
+		t.Error("Expected tombstone mode to move stale pages")
+	}
+	tombstone := filepath.Join(outputDir, tombstoneDirName, "20250314T092653Z", "DOC", "pages", "2_Gone")
+	if _, err := os.Stat(tombstone); err != nil {
+		t.Errorf("Expected tombstoned page at %s: %v", tombstone, err)
+	}
+	if _, err := os.Stat(filepath.Join(pagesDir, "3_New Title")); err != nil {
+		t.Errorf("Expected live page to be kept: %v", err)
+	}
+
+	// Remove deletes stale attachments outright
+	cl.Deletions = DeletionRemove
+	cl.handleStale(attachmentsDir, staleFiles, "attachment")
+	if _, err := os.Stat(filepath.Join(attachmentsDir, "removed.png")); !os.IsNotExist(err) {
+		t.Error("Expected stale attachment to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(attachmentsDir, "kept.png")); err != nil {
+		t.Errorf("Expected current attachment to be kept: %v", err)
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	outputDir := t.TempDir()
+
+	cp, err := openCheckpoint(outputDir, false)
+	if err != nil {
+		t.Fatalf("openCheckpoint failed: %v", err)
+	}
+	cp.complete(checkpointPage, "1", 3)
+	cp.complete(checkpointAttachment, "att1", 1)
+	cp.fail(checkpointPage, "2", fmt.Errorf("connection reset"))
+	if err := cp.flush(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	// A fresh run ignores the journal
+	fresh, err := openCheckpoint(outputDir, false)
+	if err != nil {
+		t.Fatalf("openCheckpoint failed: %v", err)
+	}
+	if fresh.done(checkpointPage, "1", 3) {
+		t.Error("Expected a non-resumed run to start from scratch")
+	}
+
+	// A resumed run skips completed work at the same version and retries failures
+	resumed, err := openCheckpoint(outputDir, true)
+	if err != nil {
+		t.Fatalf("openCheckpoint with resume failed: %v", err)
+	}
+	if !resumed.done(checkpointPage, "1", 3) || !resumed.done(checkpointAttachment, "att1", 1) {
+		t.Error("Expected completed work to be loaded from the journal")
+	}
+	if resumed.done(checkpointPage, "1", 4) {
+		t.Error("Expected a page edited since the checkpoint to be cloned again")
+	}
+	if resumed.done(checkpointPage, "2", 0) || resumed.failures() != 0 {
+		t.Error("Expected failed pages to be retried")
+	}
+
+	if err := resumed.remove(); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, checkpointFileName)); !os.IsNotExist(err) {
+		t.Error("Expected journal to be removed")
+	}
+}
+
+func TestCheckpointWriteError(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "missing")
+	cp, err := openCheckpoint(outputDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first change is written at once, and fails while the directory is missing
+	cp.complete(checkpointPage, "1", 1)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.flush(); err == nil {
+		t.Error("Expected flush to report the failed write")
+	}
+	if !fileExists(filepath.Join(outputDir, checkpointFileName)) {
+		t.Error("Expected flush to write the journal once it can")
+	}
+	if err := cp.flush(); err != nil {
+		t.Errorf("Expected the failure to be reported once, got %v", err)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	f := Filters{
+		IncludeSpaces: []string{"ENG-*", "DOC"},
+		ExcludeSpaces: []string{"ENG-OLD"},
+		SpaceTypes:    []string{"global"},
+		IncludeTitle:  regexp.MustCompile(`(?i)runbook`),
+		ExcludeTitle:  regexp.MustCompile(`DRAFT`),
+		IncludeLabels: []string{"ops"},
+		ExcludeLabels: []string{"obsolete"},
+		ModifiedAfter: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	spaces := []struct {
+		space client.Space
+		want  bool
+	}{
+		{client.Space{Key: "ENG-PLATFORM", Type: "global"}, true},
+		{client.Space{Key: "doc", Type: "global"}, true},
+		{client.Space{Key: "ENG-OLD", Type: "global"}, false},
+		{client.Space{Key: "HR", Type: "global"}, false},
+		{client.Space{Key: "DOC", Type: "personal"}, false},
+	}
+	for _, tt := range spaces {
+		if got := f.matchSpace(tt.space); got != tt.want {
+			t.Errorf("matchSpace(%s/%s) = %v, want %v", tt.space.Key, tt.space.Type, got, tt.want)
+		}
+	}
+
+	// Globs can't be sent to the API, but a single type can
+	if q := f.spaceQuery(); q.Has("keys") || q.Get("type") != "global" {
+		t.Errorf("Unexpected space query %v", q)
+	}
+	if q := (&Filters{IncludeSpaces: []string{"DOC", "ENG"}}).spaceQuery(); q.Get("keys") != "DOC,ENG" {
+		t.Errorf("Expected literal keys to be sent to the API, got %v", q)
+	}
+
+	// Keys are sent and matched the same way: upper case, except personal keys
+	lower := &Filters{IncludeSpaces: []string{"doc", "~5b10ac8d82e05b22cc7d4ef5"}}
+	if q := lower.spaceQuery(); q.Get("keys") != "DOC,~5b10ac8d82e05b22cc7d4ef5" {
+		t.Errorf("Expected normalised keys to be sent to the API, got %v", q)
+	}
+	for key, want := range map[string]bool{"DOC": true, "~5b10ac8d82e05b22cc7d4ef5": true, "~5B10AC8D82E05B22CC7D4EF5": false} {
+		if got := lower.matchSpace(client.Space{Key: key}); got != want {
+			t.Errorf("matchSpace(%s) = %v, want %v", key, got, want)
+		}
+	}
+
+	recent := &client.Version{When: "2025-06-01T00:00:00Z"}
+	old := &client.Version{When: "2024-06-01T00:00:00Z"}
+	if !f.matchContent("Database Runbook", recent) {
+		t.Error("Expected recent runbook to match")
+	}
+	if f.matchContent("Database Runbook", old) || f.matchContent("Runbook DRAFT", recent) || f.matchContent("Notes", recent) {
+		t.Error("Expected old, excluded and non-matching titles to be filtered out")
+	}
+
+	if !f.matchLabels([]string{"OPS", "db"}) || f.matchLabels([]string{"ops", "obsolete"}) || f.matchLabels(nil) {
+		t.Error("Unexpected label filter result")
+	}
+
+	tree := buildPageTree([]client.Page{
+		{ID: "1", Title: "Home"},
+		{ID: "2", Title: "Runbooks", ParentID: "1"},
+		{ID: "3", Title: "Database", ParentID: "2"},
+	}, LayoutFlat)
+	subtree := Filters{SubtreeRoots: []string{"2"}}
+	if subtree.inSubtree(tree, "1") || !subtree.inSubtree(tree, "2") || !subtree.inSubtree(tree, "3") {
+		t.Error("Expected only the subtree root and its descendants to match")
+	}
+}
+
+func TestStatusPolicies(t *testing.T) {
+	cl := &Cloner{}
+	if cl.includePersonalSpaces() || cl.includeArchivedContent() || !cl.completeListing() {
+		t.Error("Expected personal spaces and archived content to be skipped by default")
+	}
+
+	cl.Filters.SpaceTypes = []string{"personal"}
+	if !cl.includePersonalSpaces() {
+		t.Error("Expected a personal space type filter to opt in to personal spaces")
+	}
+
+	cl.ContentStatuses = []string{"current", "trashed"}
+	if cl.completeListing() {
+		t.Error("Expected a listing without archived content to disable deletion detection")
+	}
+	if q := cl.contentQuery(); fmt.Sprint(q["status"]) != "[current trashed]" {
+		t.Errorf("Unexpected status query %v", q)
+	}
+
+	cl.ContentStatuses = append(cl.ContentStatuses, "archived")
+	if !cl.includeArchivedContent() || !cl.completeListing() {
+		t.Error("Expected listing archived content to include it")
+	}
+
+	for status, want := range map[string]string{"current": "", "archived": "", "draft": "draft", "trashed": "trashed"} {
+		if got := fetchStatus(status); got != want {
+			t.Errorf("fetchStatus(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	// Home page 1 with sections 2 and 3; pages 4-7 under 2, page 8 under 3
+	pages := []client.Page{
+		{ID: "1", Title: "Home", CreatedAt: "2020-01-01T00:00:00Z"},
+		{ID: "2", Title: "A", ParentID: "1", CreatedAt: "2021-01-01T00:00:00Z"},
+		{ID: "3", Title: "B", ParentID: "1", CreatedAt: "2019-01-01T00:00:00Z"},
+		{ID: "4", Title: "A1", ParentID: "2", Version: &client.Version{When: "2025-03-01T00:00:00Z"}},
+		{ID: "5", Title: "A2", ParentID: "2", Version: &client.Version{When: "2025-01-01T00:00:00Z"}},
+		{ID: "6", Title: "A3", ParentID: "4", Version: &client.Version{When: "2025-02-01T00:00:00Z"}},
+		{ID: "7", Title: "A4", ParentID: "2"},
+		{ID: "8", Title: "B1", ParentID: "3"},
+	}
+	tree := buildPageTree(pages, LayoutFlat)
+	if got := tree.section("6"); got != "2" {
+		t.Errorf("Expected page 6 in section 2, got %q", got)
+	}
+	space := client.Space{ID: "100", Key: "DOC"}
+
+	pick := func(strategy SampleStrategy, seed int64, n int, listing []client.Page) string {
+		cl := &Cloner{SamplePages: n, SampleStrategy: strategy, SampleSeed: seed}
+		if err := cl.startSample(); err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, page := range cl.samplePages(space, listing, tree) {
+			ids = append(ids, page.ID)
+		}
+		slices.Sort(ids)
+		return fmt.Sprint(ids)
+	}
+
+	if got := pick(SampleRecent, 1, 2, pages); got != "[4 6]" {
+		t.Errorf("recent: got %s", got)
+	}
+	if got := pick(SampleOldest, 1, 2, pages); got != "[1 3]" {
+		t.Errorf("oldest: got %s", got)
+	}
+
+	// The same seed picks the same pages whatever order they are listed in
+	reversed := slices.Clone(pages)
+	slices.Reverse(reversed)
+	first := pick(SampleRandom, 42, 3, pages)
+	again := pick(SampleRandom, 42, 3, reversed)
+	if first != again {
+		t.Errorf("Expected seed 42 to pick the same sample, got %s and %s", first, again)
+	}
+
+	// Section-stratified samples cover every section before doubling up on one
+	for seed := int64(1); seed <= 20; seed++ {
+		got := pick(SampleStratifiedSections, seed, 3, pages)
+		if !strings.Contains(got, "1") || !strings.Contains(got, "3") && !strings.Contains(got, "8") {
+			t.Errorf("stratified-sections seed %d: expected home page and section 3, got %s", seed, got)
+		}
+	}
+
+	// Stratified-per-space samples split the page budget evenly between spaces
+	spaces := []client.Space{space, {ID: "200", Key: "ENG"}, {ID: "300", Key: "OPS"}}
+	perSpace := &Cloner{SamplePages: 5, SampleStrategy: SampleStratifiedPerSpace, SampleSeed: 3}
+	if err := perSpace.startSample(); err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, s := range perSpace.sampleSpaces(spaces) {
+		quota := perSpace.pageQuota(s)
+		if quota != 1 && quota != 2 {
+			t.Errorf("Expected space %s to get 1 or 2 of 5 pages, got %d", s.Key, quota)
+		}
+		total += quota
+	}
+	if total != 5 {
+		t.Errorf("Expected the quotas to add up to 5, got %d", total)
+	}
+	if got := perSpace.samplePages(space, pages, tree); len(got) != perSpace.pageQuota(space) {
+		t.Errorf("Expected %d pages from space DOC, got %d", perSpace.pageQuota(space), len(got))
+	}
+
+	for name, want := range map[string]SampleStrategy{"": SampleRandom, "Stratified-Per-Space": SampleStratifiedPerSpace, "stratified-sections": SampleStratifiedSections} {
+		if got, err := ParseSampleStrategy(name); err != nil || got != want {
+			t.Errorf("ParseSampleStrategy(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseSampleStrategy("stratified"); err == nil {
+		t.Error("Expected an error for the retired strategy name \"stratified\"")
+	}
+
+	// A written manifest replays the same pages
+	dir := t.TempDir()
+	cl := &Cloner{outputDir: dir, SamplePages: 3, SampleSeed: 7}
+	if err := cl.startSample(); err != nil {
+		t.Fatal(err)
+	}
+	sampled := cl.samplePages(space, pages, tree)
+	if err := cl.sampled.save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	replay := &Cloner{outputDir: t.TempDir(), SampleReplay: filepath.Join(dir, sampleManifestFileName)}
+	if err := replay.startSample(); err != nil {
+		t.Fatal(err)
+	}
+	if spaces := replay.sampleSpaces([]client.Space{space, {ID: "200", Key: "ENG"}}); len(spaces) != 1 || spaces[0].ID != "100" {
+		t.Errorf("Expected replay to keep only space 100, got %v", spaces)
+	}
+	if got := replay.samplePages(space, pages, tree); fmt.Sprint(got) != fmt.Sprint(sampled) {
+		t.Errorf("Expected replay to pick %v, got %v", sampled, got)
+	}
+	if replay.sampled.Seed != 7 {
+		t.Errorf("Expected replay to keep seed 7, got %d", replay.sampled.Seed)
+	}
+}
+
+func TestPlanEstimate(t *testing.T) {
+	cl := &Cloner{
+		client:         client.NewClient("example.atlassian.net", "user@example.com", "test-token"),
+		exportMarkdown: true,
+		ExportComments: true,
+		VersionHistory: 2,
+	}
+	bodyBytes := 100
+	plan := &Plan{Spaces: []PlanSpace{{
+		Key:         "DOC",
+		listedPages: 150,
+		Pages:       []PlanContent{{ID: "1", Version: 3, BodyBytes: &bodyBytes, Attachments: 2, AttachmentBytes: 5000}},
+		BlogPosts:   []PlanContent{{ID: "9", Version: 1}},
+	}}}
+	cl.estimate(plan, 4)
+
+	// Space: labels, two page listing calls and one blog post listing call.
+	// Page: labels, attachment listing, 2 downloads, the comment listing, version
+	// listing and one older version. Blog post: labels, attachment listing, the
+	// comment listing and the version listing. Bodies come with the listings.
+	space := plan.Spaces[0]
+	if space.EstimatedAPICalls != 4+7+4 {
+		t.Errorf("Expected 15 API calls for the space, got %d", space.EstimatedAPICalls)
+	}
+	if plan.Totals.EstimatedAPICalls != 1+4+15 {
+		t.Errorf("Expected 20 API calls in total, got %d", plan.Totals.EstimatedAPICalls)
+	}
+
+	// A small sample fetches each page's body on its own
+	cl.SamplePages = 5
+	cl.estimate(plan, 4)
+	if got := plan.Spaces[0].EstimatedAPICalls; got != 4+8+5 {
+		t.Errorf("Expected 17 API calls for a sampled space, got %d", got)
+	}
+
+	// Space files; page metadata, HTML, markdown, attachments, sidecars, comments and
+	// two saved versions; blog post metadata, comments and its one version's
+	// metadata, since its body size is unknown
+	wantBytes := int64(3*1024 + (1024 + 200 + 5000 + 2*1024 + 1024 + 2*(100+1024)) + (1024 + 1024 + 1024))
+	if space.EstimatedDiskBytes != wantBytes || plan.Totals.EstimatedDiskBytes != wantBytes {
+		t.Errorf("Expected %d bytes on disk, got %d (total %d)", wantBytes, space.EstimatedDiskBytes, plan.Totals.EstimatedDiskBytes)
+	}
+
+	if plan.Totals.Pages != 1 || plan.Totals.BlogPosts != 1 || plan.Totals.Attachments != 2 || plan.Totals.AttachmentBytes != 5000 || plan.Totals.UnsizedBodies != 1 {
+		t.Errorf("Unexpected totals %+v", plan.Totals)
+	}
+
+	for n, want := range map[int64]string{512: "512 B", 1536: "1.5 KiB", 3 << 30: "3.0 GiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestWorkerPoolShared(t *testing.T) {
+	cl := &Cloner{PageWorkers: 2}
+	cl.startWorkers()
+
+	// Two spaces' pages share the two page workers
+	var mu sync.Mutex
+	running, peak, calls := 0, 0, 0
+	work := func(int) {
+		mu.Lock()
+		running++
+		calls++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for space := 0; space < 2; space++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cl.forEachConcurrent(context.Background(), cl.pagePool, 4, work); err != nil {
+				t.Errorf("forEachConcurrent failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 8 || peak != 2 {
+		t.Errorf("Expected 8 calls with at most 2 at once, got %d calls and %d at once", calls, peak)
+	}
+
+	// Cancellation stops new work
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cl.forEachConcurrent(ctx, cl.pagePool, 3, func(int) { t.Error("Expected no work after cancellation") }); err == nil {
+		t.Error("Expected a cancelled context to be reported")
+	}
+}
+
+func TestAttachmentFilters(t *testing.T) {
+	filters := AttachmentFilters{
+		MaxSize:           1 << 20,
+		ExcludeMediaTypes: []string{"video/*"},
+		ExcludeExtensions: []string{".iso"},
+	}
+	tests := []struct {
+		attachment client.Attachment
+		reason     string
+	}{
+		{client.Attachment{Title: "a.png", MediaType: "image/png", FileSize: 100}, ""},
+		{client.Attachment{Title: "big.png", MediaType: "image/png", FileSize: 2 << 20}, "larger than 1.0 MiB"},
+		{client.Attachment{Title: "clip.mp4", MediaType: "video/mp4; codecs=avc1", FileSize: 100}, `media type "video/mp4" excluded`},
+		{client.Attachment{Title: "disk.ISO", MediaType: "application/octet-stream", FileSize: 100}, `extension "ISO" excluded`},
+	}
+	for _, tt := range tests {
+		if got := filters.skipReason(tt.attachment); got != tt.reason {
+			t.Errorf("skipReason(%s) = %q, want %q", tt.attachment.Title, got, tt.reason)
+		}
+	}
+
+	included := AttachmentFilters{IncludeMediaTypes: []string{"image/*"}, IncludeExtensions: []string{"png"}}
+	if got := included.skipReason(client.Attachment{Title: "a.png", MediaType: "image/png"}); got != "" {
+		t.Errorf("Expected an included PNG to be kept, got %q", got)
+	}
+	if got := included.skipReason(client.Attachment{Title: "a.gif", MediaType: "image/gif"}); got != `extension "gif" not included` {
+		t.Errorf("Expected a GIF to be skipped by extension, got %q", got)
+	}
+
+	// Metadata-only writes sidecars and records the attachments as skipped
+	dir := t.TempDir()
+	if err := saveJSON(filepath.Join(dir, "metadata.json"), map[string]interface{}{"id": "1", "version": 3}); err != nil {
+		t.Fatal(err)
+	}
+	cl := &Cloner{AttachmentFilters: AttachmentFilters{MetadataOnly: true}}
+	attachments := []client.Attachment{{ID: "att1", Title: "report.pdf", MediaType: "application/pdf", FileSize: 10}}
+	saved, err := cl.saveAttachments(context.Background(), attachments, dir, nil)
+	if err != nil {
+		t.Fatalf("saveAttachments failed: %v", err)
+	}
+	if _, ok := saved["att1"]; !ok {
+		t.Error("Expected a metadata-only attachment to count as saved")
+	}
+	if !fileExists(filepath.Join(dir, "attachments", "report.pdf.json")) {
+		t.Error("Expected the attachment sidecar to be written")
+	}
+	if fileExists(filepath.Join(dir, "attachments", "report.pdf")) {
+		t.Error("Expected the attachment file not to be downloaded")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"reason": "metadata only"`) || !strings.Contains(string(data), `"version": 3`) {
+		t.Errorf("Expected metadata.json to list the skipped attachment, got %s", data)
+	}
+
+	// A later run with nothing skipped clears the list
+	if err := recordSkippedAttachments(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if strings.Contains(string(data), "skippedAttachments") {
+		t.Errorf("Expected skippedAttachments to be removed, got %s", data)
+	}
+}
+
+func TestBlobStore(t *testing.T) {
+	outputDir := t.TempDir()
+	attachment := client.Attachment{ID: "att1", Title: "logo.png", FileSize: 4, Version: &client.Version{Number: 1}}
+	prev := map[string]attachmentState{"att1": newAttachmentState(attachment, "logo.png")}
+
+	// Two pages hold the same file, downloaded before the store was enabled
+	var pageDirs []string
+	for _, name := range []string{"1_A", "2_B"} {
+		dir := filepath.Join(outputDir, "SPACE", "pages", name)
+		if err := os.MkdirAll(filepath.Join(dir, "attachments"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "attachments", "logo.png"), []byte("logo"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		pageDirs = append(pageDirs, dir)
+	}
+
+	// No client: any attempt to download would panic
+	cl := &Cloner{outputDir: outputDir, Incremental: true, BlobStore: BlobStoreHardlink}
+	for _, dir := range pageDirs {
+		if _, err := cl.saveAttachments(context.Background(), []client.Attachment{attachment}, dir, prev); err != nil {
+			t.Fatalf("saveAttachments failed: %v", err)
+		}
+	}
+
+	hash, err := sidecarHash(filepath.Join(pageDirs[0], "attachments", "logo.png.json"))
+	if err != nil || hash == "" {
+		t.Fatalf("Expected the sidecar to record the blob, got %q (%v)", hash, err)
+	}
+	blob, err := os.Stat(cl.blobPath(hash))
+	if err != nil {
+		t.Fatalf("Expected the blob to be stored: %v", err)
+	}
+	for _, dir := range pageDirs {
+		file, err := os.Stat(filepath.Join(dir, "attachments", "logo.png"))
+		if err != nil || !os.SameFile(file, blob) {
+			t.Errorf("Expected %s to be a hard link to the blob", dir)
+		}
+	}
+
+	// Reference mode leaves only the sidecars
+	cl.BlobStore = BlobStoreReference
+	if _, err := cl.saveAttachments(context.Background(), []client.Attachment{attachment}, pageDirs[0], prev); err != nil {
+		t.Fatalf("saveAttachments failed: %v", err)
+	}
+	if fileExists(filepath.Join(pageDirs[0], "attachments", "logo.png")) {
+		t.Error("Expected reference mode to remove the page's copy")
+	}
+
+	// A blob is kept while any sidecar names it
+	if removed, _, err := cl.CollectBlobs(); err != nil || removed != 0 {
+		t.Errorf("Expected no blobs removed, got %d (%v)", removed, err)
+	}
+	for _, dir := range pageDirs {
+		if err := os.RemoveAll(filepath.Join(dir, "attachments")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	removed, freed, err := cl.CollectBlobs()
+	if err != nil || removed != 1 || freed != 4 {
+		t.Errorf("Expected the unreferenced blob to be removed, got %d blob(s), %d bytes (%v)", removed, freed, err)
+	}
+	if fileExists(cl.blobPath(hash)) {
+		t.Error("Expected the blob to be gone")
+	}
+	if fileExists(filepath.Dir(cl.blobPath(hash))) {
+		t.Error("Expected the emptied fan-out directory to be removed")
+	}
+	if !fileExists(filepath.Join(outputDir, blobDirName)) {
+		t.Error("Expected the blob store to stay while it is enabled")
+	}
+}
+
+func TestBlobStoreSwitchedOff(t *testing.T) {
+	outputDir := t.TempDir()
+	attachment := client.Attachment{ID: "att1", Title: "logo.png", FileSize: 4, Version: &client.Version{Number: 1}}
+	prev := map[string]attachmentState{"att1": newAttachmentState(attachment, "logo.png")}
+
+	// One page holds a hard link to the blob, the other only a reference stub
+	var pageDirs []string
+	for _, name := range []string{"1_A", "2_B"} {
+		dir := filepath.Join(outputDir, "SPACE", "pages", name)
+		if err := os.MkdirAll(filepath.Join(dir, "attachments"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "attachments", "logo.png"), []byte("logo"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		pageDirs = append(pageDirs, dir)
+	}
+	cl := &Cloner{outputDir: outputDir, Incremental: true}
+	for i, mode := range []BlobStore{BlobStoreHardlink, BlobStoreReference} {
+		cl.BlobStore = mode
+		if _, err := cl.saveAttachments(context.Background(), []client.Attachment{attachment}, pageDirs[i], prev); err != nil {
+			t.Fatalf("saveAttachments failed: %v", err)
+		}
+	}
+	hash, _ := sidecarHash(filepath.Join(pageDirs[0], "attachments", "logo.png.json"))
+	if hash == "" {
+		t.Fatal("Expected the sidecar to record the blob")
+	}
+
+	// With the store off, each page gets its own file and a plain sidecar,
+	// without downloading anything
+	cl.BlobStore = BlobStoreOff
+	blob, err := os.Stat(cl.blobPath(hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range pageDirs {
+		if _, err := cl.saveAttachments(context.Background(), []client.Attachment{attachment}, dir, prev); err != nil {
+			t.Fatalf("saveAttachments failed: %v", err)
+		}
+		path := filepath.Join(dir, "attachments", "logo.png")
+		if data, err := os.ReadFile(path); err != nil || string(data) != "logo" {
+			t.Errorf("Expected %s back in place, got %q (%v)", path, data, err)
+		}
+		if file, err := os.Stat(path); err == nil && os.SameFile(file, blob) {
+			t.Errorf("Expected %s to be a copy rather than a link to the blob", path)
+		}
+		if h, err := sidecarHash(path + ".json"); err != nil || h != "" {
+			t.Errorf("Expected %s.json to no longer name a blob, got %q (%v)", path, h, err)
+		}
+	}
+
+	// The blob is then unused, and the emptied store goes with it
+	removed, _, err := cl.CollectBlobs()
+	if err != nil || removed != 1 {
+		t.Errorf("Expected the unused blob to be removed, got %d (%v)", removed, err)
+	}
+	if fileExists(filepath.Join(outputDir, blobDirName)) {
+		t.Error("Expected the empty blob store to be removed")
+	}
+}
+
+func TestSafeNames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"CON.txt", "CON_.txt"},
+		{"nul", "nul_"},
+		{"com1.tar.gz", "com1_.tar.gz"},
+		{"console.log", "console.log"},
+		{"report. ", "report"},
+		{".env", "_env"},
+		{"...", "_"},
+		{"tab\there", "tab_here"},
+		{"été.png", "été.png"},
+		{"e\u0301te\u0301.png", "\u00e9t\u00e9.png"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeFilename(tt.input); got != tt.expected {
+			t.Errorf("sanitizeFilename(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+
+	// Truncation keeps whole runes and the extension
+	long := strings.Repeat("é", 150) + ".pdf"
+	name := safeName(long, maxAttachmentNameBytes)
+	if len(name) > maxAttachmentNameBytes || !utf8.ValidString(name) || !strings.HasSuffix(name, ".pdf") {
+		t.Errorf("safeName(%d bytes) = %q", len(long), name)
+	}
+	if name := sanitizeFilename(strings.Repeat("日本", 100)); len(name) > maxNameBytes || !utf8.ValidString(name) {
+		t.Errorf("Expected a valid name of at most %d bytes, got %d bytes", maxNameBytes, len(name))
+	}
+
+	// Long space keys stay unique
+	a, b := spaceDirName("~"+strings.Repeat("a", 60)+"1"), spaceDirName("~"+strings.Repeat("a", 60)+"2")
+	if a == b || len(a) > maxSpaceDirBytes || len(b) > maxSpaceDirBytes {
+		t.Errorf("Expected distinct short space directories, got %q and %q", a, b)
+	}
+}
+
+func TestAttachmentFileNames(t *testing.T) {
+	names := attachmentFileNames([]client.Attachment{
+		{ID: "10", Title: "a:b.png"},
+		{ID: "9", Title: "a/b.png"},
+		{ID: "11", Title: "A_B.PNG"},
+		{ID: "12", Title: "a_b.png.json"},
+		{ID: "13", Title: "café.txt"},
+		{ID: "14", Title: "café.txt"},
+		{ID: "15", Title: "unique.pdf"},
+	})
+	expected := map[string]string{
+		"9":  "a_b.png",
+		"10": "a_b (10).png",
+		"11": "A_B (11).PNG",
+		"12": "a_b.png (12).json",
+		"13": "café.txt",
+		"14": "café (14).txt",
+		"15": "unique.pdf",
+	}
+	for id, want := range expected {
+		if names[id] != want {
+			t.Errorf("Attachment %s saved as %q, want %q", id, names[id], want)
+		}
+	}
+
+	// Long names are kept whole up to the attachment budget, and cut names
+	// still tell apart with room for the sidecar's .json
+	long := strings.Repeat("Quarterly report ", 10) + ".xlsx"
+	longer := strings.Repeat("Quarterly report ", 15) + ".xlsx"
+	names = attachmentFileNames([]client.Attachment{{ID: "20", Title: long}, {ID: "21", Title: longer}, {ID: "22", Title: longer}})
+	if names["20"] != long {
+		t.Errorf("Expected a %d-byte name to be kept, got %q", len(long), names["20"])
+	}
+	for _, id := range []string{"21", "22"} {
+		if name := names[id]; len(name+".json") > maxNameBytes || !strings.HasSuffix(name, ".xlsx") {
+			t.Errorf("Attachment %s saved as %q (%d bytes), over the %d byte budget", id, name, len(name), maxAttachmentNameBytes)
+		}
+	}
+	if names["21"] == names["22"] || !strings.HasSuffix(names["22"], " (22).xlsx") {
+		t.Errorf("Expected shortened names to stay distinct, got %q and %q", names["21"], names["22"])
+	}
+
+	// Names don't depend on listing order
+	again := attachmentFileNames([]client.Attachment{{ID: "10", Title: "a:b.png"}, {ID: "9", Title: "a/b.png"}})
+	if again["9"] != "a_b.png" || again["10"] != "a_b (10).png" {
+		t.Errorf("Expected the same names in any order, got %v", again)
+	}
+}
+
+func TestNestedDirsFitPathBudget(t *testing.T) {
+	// Page and blog post directories keep room for a readable title, and with
+	// ordinary attachment names a path leaves the output directory 40 bytes of
+	// Windows' 260
+	for container, budget := range map[string]int{"pages": pageDirBytes, "blogposts": blogPostDirBytes} {
+		if budget < 64 {
+			t.Errorf("Expected at least 64 bytes for %s directories, got %d", container, budget)
+		}
+		full := maxSpaceDirBytes + len("/"+container+"/") + budget + len("/attachments/") + maxAttachmentNameBytes + len(".json")
+		if full > maxPathBytes {
+			t.Errorf("Longest %s path is %d bytes, over the %d byte budget", container, full, maxPathBytes)
+		}
+		if ordinary := full - maxAttachmentNameBytes + 64; ordinary > 220 {
+			t.Errorf("Expected a %s path with a 64-byte attachment name within 220 bytes, got %d", container, ordinary)
+		}
+	}
+
+	title := strings.Repeat("Quarterly planning ", 10)
+	pages := []client.Page{{ID: "100000001", Title: title}}
+	for i := 2; i <= 4; i++ {
+		pages = append(pages, client.Page{ID: fmt.Sprintf("10000000%d", i), Title: title, ParentID: fmt.Sprintf("10000000%d", i-1)})
+	}
+	tree := buildPageTree(pages, LayoutNested)
+
+	parent := ""
+	for _, page := range pages[:3] {
+		dir := tree.dir(page.ID, page.Title)
+		if len(dir) > pageDirBytes {
+			t.Errorf("Directory %q is %d bytes, over the %d byte budget", dir, len(dir), pageDirBytes)
+		}
+		if parent != "" && !strings.HasPrefix(dir, parent+string(filepath.Separator)) {
+			t.Errorf("Expected %q inside its parent's directory %q", dir, parent)
+		}
+		if !pageDirRe.MatchString(filepath.Base(dir)) {
+			t.Errorf("Expected %q to keep its page ID", dir)
+		}
+		parent = dir
+	}
+
+	flat := buildPageTree(pages, LayoutFlat)
+	if dir := flat.dir(pages[0].ID, title); len(dir) > pageDirBytes || !strings.HasPrefix(dir, "100000001_Quarterly planning") {
+		t.Errorf("Expected a shortened flat directory, got %q", dir)
+	}
+}
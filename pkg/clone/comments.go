@@ -0,0 +1,140 @@
+package clone
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+	"github.com/nycmonkey/confluence-reader/pkg/markdown"
+)
+
+// maxReplyDepth bounds how deep reply threads are nested
+const maxReplyDepth = 10
+
+// commentThread is a comment with its replies, as saved to comments.json
+type commentThread struct {
+	ID               string          `json:"id"`
+	Type             string          `json:"type"` // "footer" or "inline"
+	Status           string          `json:"status,omitempty"`
+	AuthorID         string          `json:"authorId,omitempty"`
+	CreatedAt        string          `json:"createdAt,omitempty"`
+	Version          int             `json:"version,omitempty"`
+	Body             string          `json:"body"`
+	InlineSelection  string          `json:"inlineSelection,omitempty"`
+	InlineMarkerRef  string          `json:"inlineMarkerRef,omitempty"`
+	ResolutionStatus string          `json:"resolutionStatus,omitempty"`
+	Replies          []commentThread `json:"replies,omitempty"`
+}
+
+// fetchComments retrieves a page's or blog post's footer and inline comments,
+// with their replies, in a single listing
+func (cl *Cloner) fetchComments(ctx context.Context, item content) ([]commentThread, error) {
+	comments, err := cl.client.GetContentCommentsContext(ctx, item.describe().id)
+	if err != nil {
+		return nil, err
+	}
+	return commentThreads(comments), nil
+}
+
+// saveComments writes comment threads to comments.json in dir, removing the
+// file once there are none
+func saveComments(dir string, threads []commentThread) error {
+	path := filepath.Join(dir, "comments.json")
+	if len(threads) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return saveJSON(path, threads)
+}
+
+// commentThreads nests replies under the comments they answer, with footer
+// threads before inline ones and each kept in listing order. A reply whose
+// parent isn't listed starts a thread of its own.
+func commentThreads(comments []client.Comment) []commentThread {
+	listed := make(map[string]bool, len(comments))
+	for _, comment := range comments {
+		listed[comment.ID] = true
+	}
+	var roots []client.Comment
+	replies := make(map[string][]client.Comment)
+	for _, comment := range comments {
+		if parent := comment.ParentCommentID; parent != "" && parent != comment.ID && listed[parent] {
+			replies[parent] = append(replies[parent], comment)
+		} else {
+			roots = append(roots, comment)
+		}
+	}
+	// "footer" sorts before "inline"
+	slices.SortStableFunc(roots, func(a, b client.Comment) int {
+		return cmp.Compare(commentKind(a), commentKind(b))
+	})
+
+	threads := make([]commentThread, 0, len(roots))
+	for _, root := range roots {
+		threads = append(threads, buildThread(root, commentKind(root), replies, 0))
+	}
+	return threads
+}
+
+// commentKind returns whether a comment is a footer or an inline comment
+func commentKind(comment client.Comment) string {
+	if comment.Location == "inline" {
+		return "inline"
+	}
+	return "footer"
+}
+
+// buildThread converts a comment and, recursively, its replies
+func buildThread(comment client.Comment, kind string, replies map[string][]client.Comment, depth int) commentThread {
+	thread := commentThread{
+		ID:               comment.ID,
+		Type:             kind,
+		Status:           comment.Status,
+		ResolutionStatus: comment.ResolutionStatus,
+	}
+	if comment.Version != nil {
+		thread.AuthorID = comment.Version.AuthorID
+		thread.CreatedAt = comment.Version.When
+		thread.Version = comment.Version.Number
+	}
+	if comment.Body != nil && comment.Body.Storage != nil {
+		thread.Body = comment.Body.Storage.Value
+	}
+	if comment.Properties != nil {
+		thread.InlineSelection = comment.Properties.OriginalSelection
+		thread.InlineMarkerRef = comment.Properties.MarkerRef
+	}
+
+	if depth < maxReplyDepth {
+		for _, reply := range replies[comment.ID] {
+			thread.Replies = append(thread.Replies, buildThread(reply, kind, replies, depth+1))
+		}
+	}
+	return thread
+}
+
+// markdownComments converts saved comment threads for the Markdown renderer,
+// naming each author as the frontmatter does
+func (cl *Cloner) markdownComments(ctx context.Context, threads []commentThread) []markdown.Comment {
+	comments := make([]markdown.Comment, 0, len(threads))
+	for _, t := range threads {
+		var author string
+		if t.AuthorID != "" {
+			author = userLabel(t.AuthorID, cl.resolveUser(ctx, t.AuthorID))
+		}
+		comments = append(comments, markdown.Comment{
+			Author:           author,
+			CreatedAt:        t.CreatedAt,
+			Body:             t.Body,
+			InlineSelection:  t.InlineSelection,
+			ResolutionStatus: t.ResolutionStatus,
+			Replies:          cl.markdownComments(ctx, t.Replies),
+		})
+	}
+	return comments
+}
@@ -0,0 +1,447 @@
+package clone
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+	"github.com/nycmonkey/confluence-reader/pkg/markdown"
+)
+
+// content is a page or blog post, so both go through one clone pipeline
+type content interface {
+	// describe returns the fields the pipeline reads
+	describe() contentInfo
+	// withBody returns the item with its storage-format body, fetching it
+	// when the listing didn't carry one
+	withBody(ctx context.Context, c *client.Client) (content, error)
+	labels(ctx context.Context, c *client.Client) ([]client.Label, error)
+	attachments(ctx context.Context, c *client.Client) ([]client.Attachment, error)
+	versions(ctx context.Context, c *client.Client) ([]client.Version, error)
+	// bodyAt returns the storage-format body of a historical version
+	bodyAt(ctx context.Context, c *client.Client, version int) (string, error)
+}
+
+// contentInfo is what the clone pipeline needs to know about a page or blog post
+type contentInfo struct {
+	kind       string // "page" or "blog post", for messages
+	checkpoint string // Kind recorded in the checkpoint journal
+	urlPath    string // Path segment of its web URL, "pages" or "blog"
+
+	id, title, status, spaceID string
+	parentID                   string
+	authorID, ownerID          string
+	createdAt                  string
+	version                    *client.Version
+	body                       string
+	hasBody                    bool
+
+	// extra holds metadata.json fields only one kind has
+	extra map[string]interface{}
+}
+
+// contentTarget is where a page or blog post is cloned to and recorded
+type contentTarget struct {
+	spaceDir string
+	spaceKey string
+	relDir   string                   // Relative to spaceDir
+	state    *spaceState              // Sync state of the space
+	items    map[string]*contentState // state.Pages or state.BlogPosts
+	children []markdown.PageLink      // Child pages linked from the Markdown; pages only
+}
+
+// cloneContent clones a single page or blog post into target
+func (cl *Cloner) cloneContent(ctx context.Context, item content, target contentTarget) error {
+	info := item.describe()
+	dir := filepath.Join(target.spaceDir, target.relDir)
+
+	// Items an interrupted run already finished are skipped entirely
+	if cl.resumed(info.checkpoint, info.id, info.version, dir) {
+		cl.logf("    Already cloned, skipping\n")
+		return nil
+	}
+
+	// Label filters need the labels before anything else is fetched
+	var labels []string
+	labelsFetched := false
+	if cl.Filters.filtersLabels() {
+		itemLabels, err := item.labels(ctx, cl.client)
+		if err != nil {
+			return fmt.Errorf("failed to get labels: %w", err)
+		}
+		labels, labelsFetched = client.LabelNames(itemLabels), true
+		if !cl.Filters.matchLabels(labels) {
+			cl.logf("    Excluded by label filters, skipping\n")
+			return nil
+		}
+	}
+
+	// Items whose version is already on disk are left as they are. Labels and
+	// comments change without a new version, so they're compared only when asked.
+	var comments []commentThread
+	commentsFetched := false
+	prev := target.state.get(target.items, info.id)
+	if cl.unchanged(prev, info.version, dir) {
+		if cl.RefreshAnnotations {
+			if !labelsFetched {
+				itemLabels, err := item.labels(ctx, cl.client)
+				if err != nil {
+					return fmt.Errorf("failed to get labels: %w", err)
+				}
+				labels, labelsFetched = client.LabelNames(itemLabels), true
+			}
+			if cl.ExportComments {
+				var err error
+				if comments, err = cl.fetchComments(ctx, item); err != nil {
+					return fmt.Errorf("failed to get comments: %w", err)
+				}
+				commentsFetched = true
+			}
+		}
+
+		if !cl.RefreshAnnotations || cl.annotationsOnDisk(dir, labels, comments) {
+			cl.logf("    Unchanged since last sync\n")
+			prev.Title, prev.Dir = info.title, target.relDir
+			target.state.set(target.items, info.id, *prev)
+			cl.checkpoint.complete(info.checkpoint, info.id, versionNumber(info.version))
+			return nil
+		}
+		cl.logf("    Labels or comments changed since last sync\n")
+	}
+
+	// The listing normally carries the body; fetch the item only when it doesn't
+	item, err := item.withBody(ctx, cl.client)
+	if err != nil {
+		return fmt.Errorf("failed to get full %s content: %w", info.kind, err)
+	}
+	info = item.describe()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", info.kind, err)
+	}
+
+	// Resolve the people behind the item
+	people := cl.resolvePeople(ctx, info)
+
+	// Save metadata
+	metadata := map[string]interface{}{
+		"id":        info.id,
+		"title":     info.title,
+		"path":      filepath.ToSlash(filepath.Join(filepath.Base(target.spaceDir), target.relDir)),
+		"status":    info.status,
+		"spaceId":   info.spaceID,
+		"createdAt": info.createdAt,
+	}
+	for k, v := range info.extra {
+		metadata[k] = v
+	}
+	if info.authorID != "" {
+		metadata["author"] = userMetadata(info.authorID, people.author)
+	}
+	if info.ownerID != "" {
+		metadata["owner"] = userMetadata(info.ownerID, people.owner)
+	}
+	if info.version != nil {
+		metadata["version"] = map[string]interface{}{
+			"number":    info.version.Number,
+			"createdAt": info.version.When,
+			"authorId":  info.version.AuthorID,
+			"message":   info.version.Message,
+		}
+		metadata["lastUpdated"] = info.version.When
+		if info.version.AuthorID != "" {
+			metadata["lastModifiedBy"] = userMetadata(info.version.AuthorID, people.modifier)
+		}
+	}
+
+	if labelsFetched {
+		metadata["labels"] = labels
+	} else if itemLabels, err := item.labels(ctx, cl.client); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		cl.logf("    Warning: Failed to get labels: %v\n", err)
+	} else {
+		labels = client.LabelNames(itemLabels)
+		metadata["labels"] = labels
+	}
+
+	if err := saveJSON(filepath.Join(dir, "metadata.json"), metadata); err != nil {
+		return fmt.Errorf("failed to save %s metadata: %w", info.kind, err)
+	}
+
+	// Get and save threaded comments
+	if cl.ExportComments && !commentsFetched {
+		if comments, err = cl.fetchComments(ctx, item); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			cl.logf("    Warning: Failed to get comments: %v\n", err)
+		} else {
+			commentsFetched = true
+		}
+	}
+	if commentsFetched {
+		if err := saveComments(dir, comments); err != nil {
+			cl.logf("    Warning: Failed to save comments: %v\n", err)
+		}
+	}
+
+	// Save the content (storage format)
+	if info.hasBody {
+		if err := writeFileAtomic(filepath.Join(dir, "content.html"), []byte(info.body)); err != nil {
+			return fmt.Errorf("failed to save %s content: %w", info.kind, err)
+		}
+
+		// Export markdown if enabled
+		if cl.exportMarkdown {
+			md, err := cl.convertToMarkdown(info, target.spaceKey, labels, people, target.children)
+			if err == nil && len(comments) > 0 {
+				var section string
+				section, err = cl.converter.RenderComments(cl.markdownComments(ctx, comments))
+				md += "\n" + section
+			}
+			if err != nil {
+				cl.logf("    Warning: Failed to convert to markdown: %v\n", err)
+			} else if err := writeFileAtomic(filepath.Join(dir, "content.md"), []byte(md)); err != nil {
+				cl.logf("    Warning: Failed to save markdown: %v\n", err)
+			}
+		}
+	}
+
+	// Save version history if enabled
+	if cl.VersionHistory != 0 {
+		if err := cl.saveVersionHistory(ctx, item, dir); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			cl.logf("    Warning: Failed to save version history: %v\n", err)
+		}
+	}
+
+	// Record the item as synced once its own files are written
+	synced := contentState{Title: info.title, Dir: target.relDir, Attachments: prev.attachments()}
+	if info.version != nil {
+		synced.Version = info.version.Number
+	}
+	defer func() { target.state.set(target.items, info.id, synced) }()
+
+	return cl.saveContentAttachments(ctx, item, info, dir, prev.attachments(), &synced)
+}
+
+// saveContentAttachments lists an item's attachments and saves them under dir,
+// recording what was saved in st. The item is complete once every attachment
+// is, and only then is its version kept in st: incremental runs skip unchanged
+// items without listing their attachments, so this has the next one retry.
+func (cl *Cloner) saveContentAttachments(ctx context.Context, item content, info contentInfo, dir string, prev map[string]attachmentState, st *contentState) error {
+	version := st.Version
+	st.Version = 0
+
+	attachments, err := item.attachments(ctx, cl.client)
+	if err != nil {
+		return fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	st.Attachments, err = cl.saveAttachments(ctx, attachments, dir, prev)
+	if err == nil && len(st.Attachments) == len(attachments) {
+		st.Version = version
+		cl.checkpoint.complete(info.checkpoint, info.id, versionNumber(info.version))
+	}
+	return err
+}
+
+// annotationsOnDisk reports whether the labels and, when exported, the
+// comments saved in dir are the ones given
+func (cl *Cloner) annotationsOnDisk(dir string, labels []string, comments []commentThread) bool {
+	var saved struct {
+		Labels []string `json:"labels"`
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err != nil || json.Unmarshal(data, &saved) != nil || !slices.Equal(saved.Labels, labels) {
+		return false
+	}
+	if !cl.ExportComments {
+		return true
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, "comments.json"))
+	if len(comments) == 0 {
+		return os.IsNotExist(err)
+	}
+	want, jsonErr := json.MarshalIndent(comments, "", "  ")
+	return err == nil && jsonErr == nil && bytes.Equal(data, want)
+}
+
+// convertToMarkdown converts a page or blog post to markdown with frontmatter
+func (cl *Cloner) convertToMarkdown(info contentInfo, spaceKey string, labels []string, people contentPeople, children []markdown.PageLink) (string, error) {
+	if !info.hasBody {
+		return "", fmt.Errorf("%s has no content", info.kind)
+	}
+
+	// Extract metadata
+	var versionNumber int
+	var updatedAt time.Time
+	var modifier string
+	if info.version != nil {
+		versionNumber = info.version.Number
+		updatedAt = parseTime(info.version.When)
+		if info.version.AuthorID != "" {
+			modifier = userLabel(info.version.AuthorID, people.modifier)
+		}
+	}
+
+	var author, owner string
+	if info.authorID != "" {
+		author = userLabel(info.authorID, people.author)
+	}
+	if info.ownerID != "" {
+		owner = userLabel(info.ownerID, people.owner)
+	}
+
+	// Build the web URL
+	webURL := ""
+	if cl.domain != "" {
+		webURL = fmt.Sprintf("https://%s/wiki/spaces/%s/%s/%s", cl.domain, spaceKey, info.urlPath, info.id)
+	}
+
+	meta := markdown.PageMetadata{
+		Title:     info.title,
+		PageID:    info.id,
+		SpaceKey:  spaceKey,
+		Version:   versionNumber,
+		Status:    info.status,
+		UpdatedAt: updatedAt,
+		CreatedAt: parseTime(info.createdAt),
+		Author:    author,
+		Owner:     owner,
+		Modifier:  modifier,
+		ParentID:  info.parentID,
+		URL:       webURL,
+		Labels:    labels,
+		Children:  children,
+	}
+
+	return cl.converter.ConvertWithMetadata(info.body, meta)
+}
+
+// pageContent adapts a page to the clone pipeline
+type pageContent struct {
+	client.Page
+}
+
+func (p pageContent) describe() contentInfo {
+	info := contentInfo{
+		kind:       "page",
+		checkpoint: checkpointPage,
+		urlPath:    "pages",
+		id:         p.ID,
+		title:      p.Title,
+		status:     p.Status,
+		spaceID:    p.SpaceID,
+		parentID:   p.ParentID,
+		authorID:   p.AuthorID,
+		ownerID:    p.OwnerID,
+		createdAt:  p.CreatedAt,
+		version:    p.Version,
+		hasBody:    p.Body != nil && p.Body.Storage != nil,
+		extra:      map[string]interface{}{"parentId": p.ParentID},
+	}
+	if info.hasBody {
+		info.body = p.Body.Storage.Value
+	}
+	return info
+}
+
+func (p pageContent) withBody(ctx context.Context, c *client.Client) (content, error) {
+	if p.HasBody() {
+		return p, nil
+	}
+	full, err := c.GetPageWithStatusContext(ctx, p.ID, fetchStatus(p.Status))
+	if err != nil {
+		return nil, err
+	}
+	return pageContent{*full}, nil
+}
+
+func (p pageContent) labels(ctx context.Context, c *client.Client) ([]client.Label, error) {
+	return c.GetPageLabelsContext(ctx, p.ID)
+}
+
+func (p pageContent) attachments(ctx context.Context, c *client.Client) ([]client.Attachment, error) {
+	return c.GetPageAttachmentsContext(ctx, p.ID)
+}
+
+func (p pageContent) versions(ctx context.Context, c *client.Client) ([]client.Version, error) {
+	return c.GetPageVersionsContext(ctx, p.ID)
+}
+
+func (p pageContent) bodyAt(ctx context.Context, c *client.Client, version int) (string, error) {
+	historical, err := c.GetPageAtVersionContext(ctx, p.ID, version)
+	if err != nil || historical.Body == nil || historical.Body.Storage == nil {
+		return "", err
+	}
+	return historical.Body.Storage.Value, nil
+}
+
+// blogPostContent adapts a blog post to the clone pipeline
+type blogPostContent struct {
+	client.BlogPost
+}
+
+func (b blogPostContent) describe() contentInfo {
+	info := contentInfo{
+		kind:       "blog post",
+		checkpoint: checkpointBlogPost,
+		urlPath:    "blog",
+		id:         b.ID,
+		title:      b.Title,
+		status:     b.Status,
+		spaceID:    b.SpaceID,
+		authorID:   b.AuthorID,
+		createdAt:  b.CreatedAt,
+		version:    b.Version,
+		hasBody:    b.Body != nil && b.Body.Storage != nil,
+		extra:      map[string]interface{}{"authorId": b.AuthorID},
+	}
+	if info.hasBody {
+		info.body = b.Body.Storage.Value
+	}
+	return info
+}
+
+func (b blogPostContent) withBody(ctx context.Context, c *client.Client) (content, error) {
+	if b.HasBody() {
+		return b, nil
+	}
+	full, err := c.GetBlogPostWithStatusContext(ctx, b.ID, fetchStatus(b.Status))
+	if err != nil {
+		return nil, err
+	}
+	return blogPostContent{*full}, nil
+}
+
+func (b blogPostContent) labels(ctx context.Context, c *client.Client) ([]client.Label, error) {
+	return c.GetBlogPostLabelsContext(ctx, b.ID)
+}
+
+func (b blogPostContent) attachments(ctx context.Context, c *client.Client) ([]client.Attachment, error) {
+	return c.GetBlogPostAttachmentsContext(ctx, b.ID)
+}
+
+func (b blogPostContent) versions(ctx context.Context, c *client.Client) ([]client.Version, error) {
+	return c.GetBlogPostVersionsContext(ctx, b.ID)
+}
+
+func (b blogPostContent) bodyAt(ctx context.Context, c *client.Client, version int) (string, error) {
+	historical, err := c.GetBlogPostAtVersionContext(ctx, b.ID, version)
+	if err != nil || historical.Body == nil || historical.Body.Storage == nil {
+		return "", err
+	}
+	return historical.Body.Storage.Value, nil
+}
@@ -0,0 +1,596 @@
+package clone
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+const apiPath = "/wiki/api/v2"
+
+// fakeConfluence serves canned API responses and counts the requests for each path
+type fakeConfluence struct {
+	mu       sync.Mutex
+	routes   map[string]string // Path, optionally with "?version=N", "?accountId=ID" or "?body-format=F", to response body
+	failures map[string]int    // Route to how many more requests get a server error
+	hits     map[string]int    // Requests by path, and by path with its version, accountId or body-format
+}
+
+// newFakeConfluence starts a fake Confluence Cloud site and returns a client
+// pointed at it that doesn't retry. v2 listings and comment listings nobody set
+// a response for come back empty, and any other unknown path is not found.
+func newFakeConfluence(t *testing.T) (*fakeConfluence, *client.Client) {
+	t.Helper()
+	f := &fakeConfluence{routes: make(map[string]string), failures: make(map[string]int), hits: make(map[string]int)}
+	server := httptest.NewTLSServer(http.HandlerFunc(f.serve))
+	t.Cleanup(server.Close)
+
+	c := client.NewClient(strings.TrimPrefix(server.URL, "https://"), "user@example.com", "test-token", client.WithHTTPClient(server.Client()))
+	c.SetRetryPolicy(client.RetryPolicy{MaxAttempts: 1})
+	return f, c
+}
+
+func (f *fakeConfluence) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	route := r.URL.Path
+	// A version or accountId picks the route over a body format
+	for _, param := range []string{"body-format", "version", "accountId"} {
+		if v := r.URL.Query().Get(param); v != "" {
+			route = r.URL.Path + "?" + param + "=" + v
+			f.hits[route]++
+		}
+	}
+	f.hits[r.URL.Path]++
+	failing := f.failures[route] > 0
+	if failing {
+		f.failures[route]--
+	}
+	body, ok := f.routes[route]
+	if !ok {
+		body, ok = f.routes[r.URL.Path]
+	}
+	f.mu.Unlock()
+
+	if failing {
+		http.Error(w, `{"message":"try again"}`, http.StatusServiceUnavailable)
+		return
+	}
+	if !ok {
+		last := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		listing := strings.HasPrefix(r.URL.Path, apiPath+"/") && strings.Trim(last, "0123456789") != "" ||
+			strings.HasSuffix(r.URL.Path, "/child/comment")
+		if !listing {
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		body = `{"results":[]}`
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(body))
+}
+
+// set serves body for a path below the v2 API, or for a full path starting with /wiki/
+func (f *fakeConfluence) set(path, body string) {
+	if !strings.HasPrefix(path, "/wiki/") {
+		path = apiPath + path
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes[path] = body
+}
+
+// list serves a single-page listing of items for a path below the v2 API
+func (f *fakeConfluence) list(path string, items ...string) {
+	f.set(path, `{"results":[`+strings.Join(items, ",")+`]}`)
+}
+
+// comments serves the v1 comment listing of a page or blog post, replies included
+func (f *fakeConfluence) comments(contentID string, items ...string) {
+	f.set("/wiki/rest/api/content/"+contentID+"/child/comment", `{"results":[`+strings.Join(items, ",")+`]}`)
+}
+
+// fail answers the next n requests for a route with a server error
+func (f *fakeConfluence) fail(path string, n int) {
+	if !strings.HasPrefix(path, "/wiki/") {
+		path = apiPath + path
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[path] = n
+}
+
+// count returns how many requests a route below the v2 API, or a full path, received
+func (f *fakeConfluence) count(path string) int {
+	if !strings.HasPrefix(path, "/wiki/") {
+		path = apiPath + path
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hits[path]
+}
+
+// space serves a single space DOC with ID 1
+func (f *fakeConfluence) space() {
+	f.list("/spaces", `{"id":"1","key":"DOC","name":"Docs","type":"global","status":"current"}`)
+}
+
+// readJSON decodes a JSON file written by the cloner
+func readJSON(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("Invalid %s: %v", path, err)
+	}
+}
+
+func TestCloneBlogPostLayout(t *testing.T) {
+	f, c := newFakeConfluence(t)
+	f.space()
+	f.list("/spaces/1/blogposts", `{"id":"9","status":"current","title":"Team Update","spaceId":"1","authorId":"acc-1",
+		"createdAt":"2025-03-14T09:30:00.000Z","version":{"number":2,"authorId":"acc-1","createdAt":"2025-03-15T10:00:00.000Z"},
+		"body":{"storage":{"value":"<p>Hello team</p>","representation":"storage"}}}`)
+	f.list("/blogposts/9/labels", `{"id":"l1","name":"news"}`)
+	f.list("/blogposts/9/attachments", `{"id":"att1","title":"notes.txt","mediaType":"text/plain","fileSize":5,
+		"version":{"number":1},"downloadLink":"/download/attachments/9/notes.txt"}`)
+	f.set("/wiki/download/attachments/9/notes.txt", "notes")
+	f.comments("9", `{"id":"c1","status":"current","version":{"number":1,"by":{"accountId":"acc-1"}},
+		"body":{"storage":{"value":"<p>Thanks</p>"}},"extensions":{"location":"footer"}}`)
+	f.list("/blogposts/9/versions", `{"number":2,"authorId":"acc-1"}`, `{"number":1,"authorId":"acc-1","message":"First draft"}`)
+	f.set("/blogposts/9?version=1", `{"id":"9","version":{"number":1},"body":{"storage":{"value":"<p>Draft</p>"}}}`)
+	f.set("/wiki/rest/api/user?accountId=acc-1", `{"accountId":"acc-1","displayName":"Ada Lovelace"}`)
+
+	out := t.TempDir()
+	cl := NewCloner(c, out, 0, 0)
+	cl.EnableMarkdownExport("example.atlassian.net")
+	cl.ExportComments = true
+	cl.VersionHistory = AllVersions
+	if err := cl.Clone(); err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+
+	postDir := filepath.Join(out, "DOC", "blogposts", "2025-03-14_9_Team Update")
+	for _, name := range []string{
+		"metadata.json", "content.html", "content.md", "comments.json",
+		"attachments/notes.txt", "attachments/notes.txt.json",
+		"versions/1/content.html", "versions/1/metadata.json", "versions/2/content.html",
+	} {
+		if !fileExists(filepath.Join(postDir, filepath.FromSlash(name))) {
+			t.Errorf("Expected %s in the blog post directory", name)
+		}
+	}
+
+	var metadata struct {
+		ID       string
+		Path     string
+		AuthorID string
+		Labels   []string
+		Author   struct{ DisplayName string }
+	}
+	readJSON(t, filepath.Join(postDir, "metadata.json"), &metadata)
+	if metadata.ID != "9" || metadata.Path != "DOC/blogposts/2025-03-14_9_Team Update" || metadata.AuthorID != "acc-1" ||
+		metadata.Author.DisplayName != "Ada Lovelace" || len(metadata.Labels) != 1 || metadata.Labels[0] != "news" {
+		t.Errorf("Unexpected blog post metadata %+v", metadata)
+	}
+
+	md, _ := os.ReadFile(filepath.Join(postDir, "content.md"))
+	for _, want := range []string{"https://example.atlassian.net/wiki/spaces/DOC/blog/9", "Hello team", "Thanks"} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("Expected content.md to contain %q:\n%s", want, md)
+		}
+	}
+	if old, _ := os.ReadFile(filepath.Join(postDir, "versions", "1", "content.html")); string(old) != "<p>Draft</p>" {
+		t.Errorf("Expected version 1 body, got %q", old)
+	}
+
+	state, err := loadSpaceState(filepath.Join(out, "DOC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := state.BlogPosts["9"]; st == nil || st.Version != 2 || st.Dir != filepath.Join("blogposts", "2025-03-14_9_Team Update") {
+		t.Errorf("Unexpected blog post sync state %+v", st)
+	}
+	if f.count("/blogposts/9") != 1 {
+		t.Errorf("Expected only the old version's body to be fetched, got %d request(s)", f.count("/blogposts/9"))
+	}
+}
+
+func TestClonePageComments(t *testing.T) {
+	f, c := newFakeConfluence(t)
+	f.space()
+	f.list("/spaces/1/pages", `{"id":"5","status":"current","title":"Policy","spaceId":"1","version":{"number":1},
+		"body":{"storage":{"value":"<p>Keep <ac:inline-comment-marker ac:ref=\"m1\">secrets</ac:inline-comment-marker> safe</p>"}}}`)
+	// The listing mixes kinds and puts the inline comment first; replies name
+	// their parent among their ancestors
+	f.comments("5",
+		`{"id":"c3","status":"current","version":{"number":1,"by":{"accountId":"acc-2"}},"body":{"storage":{"value":"<p>Which ones?</p>"}},
+			"extensions":{"location":"inline","inlineProperties":{"markerRef":"m1","originalSelection":"secrets"},"resolution":{"status":"open"}}}`,
+		`{"id":"c1","status":"current","version":{"number":1,"by":{"accountId":"acc-1"},"when":"2025-03-14T09:30:00.000Z"},
+			"body":{"storage":{"value":"<p>Looks good</p>"}},"extensions":{"location":"footer"}}`,
+		`{"id":"c2","status":"current","ancestors":[{"id":"5","type":"page"},{"id":"c1","type":"comment"}],
+			"version":{"number":1,"by":{"accountId":"acc-2"}},"body":{"storage":{"value":"<p>Agreed</p>"}},"extensions":{"location":"footer"}}`)
+
+	out := t.TempDir()
+	cl := NewCloner(c, out, 0, 0)
+	cl.EnableMarkdownExport("")
+	cl.ExportComments = true
+	if err := cl.Clone(); err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+
+	pageDir := filepath.Join(out, "DOC", "pages", "5_Policy")
+	var threads []commentThread
+	readJSON(t, filepath.Join(pageDir, "comments.json"), &threads)
+	if len(threads) != 2 {
+		t.Fatalf("Expected a footer and an inline thread, got %+v", threads)
+	}
+	footer, inline := threads[0], threads[1]
+	if footer.ID != "c1" || footer.Type != "footer" || footer.Body != "<p>Looks good</p>" || footer.AuthorID != "acc-1" {
+		t.Errorf("Unexpected footer comment %+v", footer)
+	}
+	if len(footer.Replies) != 1 || footer.Replies[0].ID != "c2" || footer.Replies[0].Type != "footer" || footer.Replies[0].Body != "<p>Agreed</p>" {
+		t.Errorf("Expected reply c2 under c1, got %+v", footer.Replies)
+	}
+	if inline.ID != "c3" || inline.Type != "inline" || inline.InlineSelection != "secrets" ||
+		inline.InlineMarkerRef != "m1" || inline.ResolutionStatus != "open" || len(inline.Replies) != 0 {
+		t.Errorf("Unexpected inline comment %+v", inline)
+	}
+	if n := f.count("/wiki/rest/api/content/5/child/comment"); n != 1 {
+		t.Errorf("Expected comments and replies in one listing, got %d request(s)", n)
+	}
+	if f.count("/footer-comments/c1/children")+f.count("/pages/5/footer-comments")+f.count("/pages/5/inline-comments") != 0 {
+		t.Error("Expected no per-comment reply requests")
+	}
+
+	md, _ := os.ReadFile(filepath.Join(pageDir, "content.md"))
+	for _, want := range []string{"## Comments", "### Comment by", "Looks good", "#### Reply by", "Agreed", "> On: \"secrets\"", "> Status: open", "Which ones?"} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("Expected content.md to contain %q:\n%s", want, md)
+		}
+	}
+	if strings.Index(string(md), "Agreed") < strings.Index(string(md), "Looks good") {
+		t.Error("Expected the reply after the comment it answers")
+	}
+}
+
+func TestCloneVersionHistory(t *testing.T) {
+	f, c := newFakeConfluence(t)
+	f.space()
+	f.list("/spaces/1/pages", `{"id":"5","status":"current","title":"Policy","spaceId":"1","version":{"number":3},
+		"body":{"storage":{"value":"<p>Third</p>"}}}`)
+	f.list("/pages/5/versions", `{"number":1,"authorId":"acc-1"}`, `{"number":3,"authorId":"acc-1","message":"Tighten"}`,
+		`{"number":2,"authorId":"acc-2","minorEdit":true}`)
+	f.set("/pages/5?version=1", `{"id":"5","version":{"number":1},"body":{"storage":{"value":"<p>First</p>"}}}`)
+	f.set("/pages/5?version=2", `{"id":"5","version":{"number":2},"body":{"storage":{"value":"<p>Second</p>"}}}`)
+	f.set("/wiki/rest/api/user?accountId=acc-1", `{"accountId":"acc-1","displayName":"Ada Lovelace","email":"ada@example.com"}`)
+
+	out := t.TempDir()
+	cl := NewCloner(c, out, 0, 0)
+	cl.VersionHistory = 2
+	if err := cl.Clone(); err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+
+	// The two most recent versions are saved; the current one comes from the listing
+	versionsDir := filepath.Join(out, "DOC", "pages", "5_Policy", "versions")
+	for n, want := range map[string]string{"3": "<p>Third</p>", "2": "<p>Second</p>"} {
+		if body, err := os.ReadFile(filepath.Join(versionsDir, n, "content.html")); err != nil || string(body) != want {
+			t.Errorf("Expected version %s to hold %q, got %q (%v)", n, want, body, err)
+		}
+	}
+	if fileExists(filepath.Join(versionsDir, "1")) {
+		t.Error("Expected version 1 to be left out")
+	}
+	type versionMeta struct {
+		Number    int
+		AuthorID  string
+		Author    map[string]string
+		Message   string
+		MinorEdit bool
+	}
+	var meta versionMeta
+	readJSON(t, filepath.Join(versionsDir, "3", "metadata.json"), &meta)
+	if meta.Number != 3 || meta.AuthorID != "acc-1" || meta.Message != "Tighten" || meta.MinorEdit {
+		t.Errorf("Unexpected version 3 metadata %+v", meta)
+	}
+	if meta.Author["displayName"] != "Ada Lovelace" || meta.Author["email"] != "ada@example.com" {
+		t.Errorf("Expected version 3 to name its author, got %v", meta.Author)
+	}
+
+	// An author who can't be resolved is still recorded by account ID
+	meta = versionMeta{}
+	readJSON(t, filepath.Join(versionsDir, "2", "metadata.json"), &meta)
+	if meta.Number != 2 || !meta.MinorEdit || meta.Author["accountId"] != "acc-2" || meta.Author["displayName"] != "" {
+		t.Errorf("Unexpected version 2 metadata %+v", meta)
+	}
+	if n := f.count("/pages/5"); n != 1 {
+		t.Errorf("Expected only version 2 to be fetched, got %d request(s)", n)
+	}
+
+	// Versions already on disk aren't fetched again
+	cl = NewCloner(c, out, 0, 0)
+	cl.VersionHistory = 2
+	if err := cl.Clone(); err != nil {
+		t.Fatalf("Second clone failed: %v", err)
+	}
+	if n := f.count("/pages/5"); n != 1 {
+		t.Errorf("Expected saved versions to be skipped, got %d request(s)", n)
+	}
+}
+
+func TestCloneResolvesUsersOnce(t *testing.T) {
+	f, c := newFakeConfluence(t)
+	f.space()
+	f.list("/spaces/1/pages",
+		`{"id":"5","status":"current","title":"One","authorId":"acc-1","ownerId":"acc-3","version":{"number":1,"authorId":"acc-1"},"body":{"storage":{"value":"<p>1</p>"}}}`,
+		`{"id":"6","status":"current","title":"Two","authorId":"acc-1","ownerId":"acc-3","version":{"number":1,"authorId":"acc-4"},"body":{"storage":{"value":"<p>2</p>"}}}`,
+		`{"id":"7","status":"current","title":"Three","authorId":"acc-1","version":{"number":1,"authorId":"acc-4"},"body":{"storage":{"value":"<p>3</p>"}}}`)
+	f.comments("5", `{"id":"c1","version":{"number":1,"by":{"accountId":"acc-2"}},"body":{"storage":{"value":"<p>Hi</p>"}}}`)
+	f.set("/wiki/rest/api/user?accountId=acc-1", `{"accountId":"acc-1","displayName":"Ada Lovelace","email":"ada@example.com"}`)
+	f.set("/wiki/rest/api/user?accountId=acc-2", `{"accountId":"acc-2","displayName":"Grace Hopper"}`)
+	f.set("/wiki/rest/api/user?accountId=acc-3", `{"accountId":"acc-3","displayName":"Alan Turing"}`)
+	f.fail("/wiki/rest/api/user?accountId=acc-3", 1)
+	// acc-4 has been deleted, so its lookups are not found
+
+	out := t.TempDir()
+	cl := NewCloner(c, out, 0, 0)
+	cl.EnableMarkdownExport("")
+	cl.ExportComments = true
+	cl.PageWorkers = 1
+	if err := cl.Clone(); err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+
+	// Resolved and missing accounts are looked up once; a failed lookup is retried
+	for id, want := range map[string]int{"acc-1": 1, "acc-2": 1, "acc-3": 2, "acc-4": 1} {
+		if got := f.count("/wiki/rest/api/user?accountId=" + id); got != want {
+			t.Errorf("Expected %d lookup(s) of %s, got %d", want, id, got)
+		}
+	}
+
+	var meta struct{ Owner struct{ DisplayName string } }
+	readJSON(t, filepath.Join(out, "DOC", "pages", "6_Two", "metadata.json"), &meta)
+	if meta.Owner.DisplayName != "Alan Turing" {
+		t.Errorf("Expected the owner to resolve once the lookup succeeds, got %+v", meta)
+	}
+
+	// Comment authors are named like the frontmatter's people
+	md, _ := os.ReadFile(filepath.Join(out, "DOC", "pages", "5_One", "content.md"))
+	if !strings.Contains(string(md), "### Comment by Grace Hopper") || strings.Contains(string(md), "by acc-2") {
+		t.Errorf("Expected the comment author's name in content.md:\n%s", md)
+	}
+}
+
+func TestIncrementalRefreshesLabelsAndComments(t *testing.T) {
+	f, c := newFakeConfluence(t)
+	f.space()
+	// The listing leaves the body out, so every full clone of the page fetches it
+	f.list("/spaces/1/pages", `{"id":"5","status":"current","title":"Policy","spaceId":"1","version":{"number":1}}`)
+	f.set("/pages/5", `{"id":"5","status":"current","title":"Policy","spaceId":"1","version":{"number":1},"body":{"storage":{"value":"<p>Text</p>"}}}`)
+	f.list("/pages/5/labels", `{"id":"l1","name":"draft"}`)
+
+	out := t.TempDir()
+	run := func() {
+		t.Helper()
+		cl := NewCloner(c, out, 0, 0)
+		cl.EnableMarkdownExport("")
+		cl.ExportComments = true
+		cl.Incremental = true
+		cl.RefreshAnnotations = true
+		if err := cl.Clone(); err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+	}
+	pageDir := filepath.Join(out, "DOC", "pages", "5_Policy")
+	labels := func() []string {
+		var meta struct{ Labels []string }
+		readJSON(t, filepath.Join(pageDir, "metadata.json"), &meta)
+		return meta.Labels
+	}
+
+	run()
+	if got := labels(); len(got) != 1 || got[0] != "draft" {
+		t.Fatalf("Expected label draft, got %v", got)
+	}
+
+	// Relabelling a page doesn't bump its version
+	f.list("/pages/5/labels", `{"id":"l2","name":"approved"}`)
+	run()
+	if got := labels(); len(got) != 1 || got[0] != "approved" {
+		t.Errorf("Expected the new label after an incremental run, got %v", got)
+	}
+	if md, _ := os.ReadFile(filepath.Join(pageDir, "content.md")); !strings.Contains(string(md), "approved") {
+		t.Errorf("Expected the new label in content.md:\n%s", md)
+	}
+	if n := f.count("/pages/5"); n != 2 {
+		t.Errorf("Expected the relabelled page to be cloned again, got %d fetch(es)", n)
+	}
+
+	// Neither is a new comment
+	f.comments("5", `{"id":"c1","version":{"number":1},"body":{"storage":{"value":"<p>Approved</p>"}}}`)
+	run()
+	if !fileExists(filepath.Join(pageDir, "comments.json")) {
+		t.Error("Expected comments.json after a comment was added")
+	}
+
+	// Once nothing changed, the page isn't fetched again
+	run()
+	if n := f.count("/pages/5"); n != 3 {
+		t.Errorf("Expected an unchanged page to be skipped, got %d fetch(es)", n)
+	}
+
+	// A deleted comment takes comments.json with it
+	f.comments("5")
+	run()
+	if fileExists(filepath.Join(pageDir, "comments.json")) {
+		t.Error("Expected comments.json to be removed with the last comment")
+	}
+}
+
+func TestAttachmentListingFailureFailsContent(t *testing.T) {
+	f, c := newFakeConfluence(t)
+	f.space()
+	f.list("/spaces/1/pages", `{"id":"5","status":"current","title":"Policy","spaceId":"1","version":{"number":1},"body":{"storage":{"value":"<p>Text</p>"}}}`)
+	f.fail("/pages/5/attachments", 2)
+
+	out := t.TempDir()
+	for _, incremental := range []bool{false, true} {
+		cl := NewCloner(c, out, 0, 0)
+		cl.Incremental = incremental
+		if err := cl.Clone(); err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+
+		// The page is recorded as failed, so the journal is kept for a retry
+		var journal struct{ Failed map[string]map[string]string }
+		readJSON(t, filepath.Join(out, checkpointFileName), &journal)
+		if msg := journal.Failed[checkpointPage]["5"]; !strings.Contains(msg, "failed to get attachments") {
+			t.Errorf("Expected the page to fail on its attachment listing (incremental %v), got %q", incremental, msg)
+		}
+	}
+}
+
+func TestPlanListsWithoutBodies(t *testing.T) {
+	f, c := newFakeConfluence(t)
+	f.space()
+	f.list("/spaces/1/pages?body-format=storage", `{"id":"5","status":"current","title":"Guide","spaceId":"1","version":{"number":1},"body":{"storage":{"value":"<p>Guide</p>","representation":"storage"}}}`)
+	f.list("/spaces/1/pages", `{"id":"5","status":"current","title":"Guide","spaceId":"1","version":{"number":1}}`)
+	f.list("/pages/5/attachments", `{"id":"att1","status":"current","title":"a.png","mediaType":"image/png","fileSize":2048,"version":{"number":1}}`)
+
+	plan, err := NewCloner(c, t.TempDir(), 0, 0).Plan()
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if n := f.count("/spaces/1/pages?body-format=storage"); n != 0 {
+		t.Errorf("Expected a dry run to list without bodies, got %d listing(s) with them", n)
+	}
+	if n := f.count("/pages/5"); n != 0 {
+		t.Errorf("Expected a dry run not to fetch the page, got %d request(s)", n)
+	}
+	if plan.Totals.Pages != 1 || plan.Totals.AttachmentBytes != 2048 || plan.Totals.UnsizedBodies != 1 {
+		t.Errorf("Expected one page with an unsized body and 2048 attachment bytes, got %+v", plan.Totals)
+	}
+}
+
+func TestCloneFetchesMissingBodies(t *testing.T) {
+	f, c := newFakeConfluence(t)
+	f.space()
+	listed := []string{
+		`{"id":"5","status":"current","title":"Listed","spaceId":"1","version":{"number":2},"body":{"storage":{"value":"<p>Listed</p>","representation":"storage"}}}`,
+		`{"id":"6","status":"current","title":"Missing","spaceId":"1","version":{"number":1}}`,
+		`{"id":"7","status":"current","title":"Truncated","spaceId":"1","version":{"number":2},"body":{"storage":{"value":"","representation":"storage"}}}`,
+		`{"id":"8","status":"current","title":"Other","spaceId":"1","version":{"number":1},"body":{"storage":{"value":"{}","representation":"atlas_doc_format"}}}`,
+	}
+	f.list("/spaces/1/pages?body-format=storage", listed...)
+	plain := []string{
+		`{"id":"5","status":"current","title":"Listed","spaceId":"1","version":{"number":2}}`,
+		`{"id":"6","status":"current","title":"Missing","spaceId":"1","version":{"number":1}}`,
+		`{"id":"7","status":"current","title":"Truncated","spaceId":"1","version":{"number":2}}`,
+		`{"id":"8","status":"current","title":"Other","spaceId":"1","version":{"number":1}}`,
+	}
+	f.list("/spaces/1/pages", plain...)
+	for _, page := range []struct{ id, title, version string }{{"5", "Listed", "3"}, {"6", "Missing", "1"}, {"7", "Truncated", "2"}, {"8", "Other", "1"}} {
+		f.set("/pages/"+page.id, `{"id":"`+page.id+`","status":"current","title":"`+page.title+`","spaceId":"1","version":{"number":`+page.version+`},"body":{"storage":{"value":"<p>Full `+page.id+`</p>","representation":"storage"}}}`)
+	}
+
+	out := t.TempDir()
+	run := func() {
+		t.Helper()
+		cl := NewCloner(c, out, 0, 0)
+		cl.Incremental = true
+		if err := cl.Clone(); err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+	}
+
+	// The first run lists bodies and fetches only the pages whose body is
+	// missing, cut short or in another format
+	run()
+	for id, want := range map[string]int{"5": 0, "6": 1, "7": 1, "8": 1} {
+		if n := f.count("/pages/" + id); n != want {
+			t.Errorf("Expected page %s to be fetched %d time(s), got %d", id, want, n)
+		}
+	}
+	if html, _ := os.ReadFile(filepath.Join(out, "DOC", "pages", "7_Truncated", "content.html")); string(html) != "<p>Full 7</p>" {
+		t.Errorf("Expected the full body of the truncated page, got %q", html)
+	}
+	if html, _ := os.ReadFile(filepath.Join(out, "DOC", "pages", "5_Listed", "content.html")); string(html) != "<p>Listed</p>" {
+		t.Errorf("Expected the listed body, got %q", html)
+	}
+
+	// A re-sync lists without bodies and fetches nothing that didn't change
+	before := map[string]int{}
+	for _, id := range []string{"5", "6", "7", "8"} {
+		before[id] = f.count("/pages/" + id)
+	}
+	run()
+	if n := f.count("/spaces/1/pages?body-format=storage"); n != 1 {
+		t.Errorf("Expected an incremental re-sync to list without bodies, got %d listing(s) with them", n)
+	}
+	for _, id := range []string{"6", "7", "8"} {
+		if n := f.count("/pages/" + id); n != before[id] {
+			t.Errorf("Expected unchanged page %s not to be fetched again, got %d more", id, n-before[id])
+		}
+	}
+
+	// Only the page that changed is fetched
+	plain[0] = `{"id":"5","status":"current","title":"Listed","spaceId":"1","version":{"number":3}}`
+	f.list("/spaces/1/pages", plain...)
+	run()
+	if n := f.count("/pages/5"); n != 1 {
+		t.Errorf("Expected the changed page to be fetched once, got %d", n)
+	}
+	if n := f.count("/pages/6"); n != before["6"] {
+		t.Errorf("Expected unchanged page 6 not to be fetched, got %d more", n-before["6"])
+	}
+}
+
+func TestIncrementalSkipsUnchangedContent(t *testing.T) {
+	f, c := newFakeConfluence(t)
+	f.space()
+	f.list("/spaces/1/pages", `{"id":"5","status":"current","title":"Policy","spaceId":"1","version":{"number":1},"body":{"storage":{"value":"<p>Text</p>"}}}`)
+	f.list("/pages/5/labels", `{"id":"l1","name":"draft"}`)
+	f.list("/pages/5/attachments",
+		`{"id":"att1","title":"a.txt","fileSize":1,"version":{"number":1},"downloadLink":"/download/attachments/5/a.txt"}`,
+		`{"id":"att2","title":"b.txt","fileSize":1,"version":{"number":1},"downloadLink":"/download/attachments/5/b.txt"}`)
+	f.set("/wiki/download/attachments/5/a.txt", "a")
+	f.set("/wiki/download/attachments/5/b.txt", "b")
+	f.fail("/wiki/download/attachments/5/b.txt", 1)
+
+	out := t.TempDir()
+	run := func() {
+		t.Helper()
+		cl := NewCloner(c, out, 0, 0)
+		cl.ExportComments = true
+		cl.Incremental = true
+		if err := cl.Clone(); err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+	}
+	perPage := func() int {
+		return f.count("/pages/5/labels") + f.count("/pages/5/attachments") + f.count("/wiki/rest/api/content/5/child/comment")
+	}
+
+	// A page with an attachment that failed to download is cloned again
+	run()
+	run()
+	if !fileExists(filepath.Join(out, "DOC", "pages", "5_Policy", "attachments", "b.txt")) {
+		t.Fatal("Expected the failed attachment to be retried")
+	}
+
+	// Once it's complete, an unchanged page costs no requests of its own
+	before := perPage()
+	run()
+	if n := perPage() - before; n != 0 {
+		t.Errorf("Expected no requests for an unchanged page, got %d", n)
+	}
+}
@@ -0,0 +1,203 @@
+package clone
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+// DeletionPolicy controls what happens to content that no longer exists upstream
+type DeletionPolicy string
+
+const (
+	// DeletionReport lists stale pages, blog posts and attachments but leaves them on disk
+	DeletionReport DeletionPolicy = "report"
+	// DeletionRemove deletes stale content from the output
+	DeletionRemove DeletionPolicy = "remove"
+	// DeletionTombstone moves stale content under _deleted/<timestamp>/ in the output directory
+	DeletionTombstone DeletionPolicy = "tombstone"
+)
+
+// tombstoneDirName is the directory under the output root that holds tombstoned content
+const tombstoneDirName = "_deleted"
+
+// ParseDeletionPolicy parses a deletion policy name, accepting "" as the report default
+func ParseDeletionPolicy(s string) (DeletionPolicy, error) {
+	switch p := DeletionPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return DeletionReport, nil
+	case DeletionReport, DeletionRemove, DeletionTombstone:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown deletion policy %q (want %q, %q or %q)", s, DeletionReport, DeletionRemove, DeletionTombstone)
+}
+
+// blogPostDirRe matches a blog post directory name and captures the blog post ID
+var blogPostDirRe = regexp.MustCompile(`^(?:[0-9]{4}-[0-9]{2}-[0-9]{2}|undated)_([0-9]+)_`)
+
+// stalePageDirs returns page directories under pagesDir, relative to it, that
+// don't hold a listed page at its current location: pages deleted or moved out
+// of the space, and directories left behind under a page's old title.
+// Only the outermost stale directory of a nested subtree is returned.
+func stalePageDirs(pagesDir string, tree *pageTree) ([]string, error) {
+	var stale []string
+	err := filepath.WalkDir(pagesDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == pagesDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() || path == pagesDir {
+			return nil
+		}
+
+		m := pageDirRe.FindStringSubmatch(d.Name())
+		if m == nil {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(pagesDir, path)
+		if err != nil {
+			return err
+		}
+		if node, ok := tree.nodes[m[1]]; ok && tree.dir(node.ID, node.Title) == rel {
+			return nil
+		}
+		stale = append(stale, rel)
+		return filepath.SkipDir
+	})
+	return stale, err
+}
+
+// staleBlogPostDirs returns blog post directories under blogDir that don't belong to a listed post
+func staleBlogPostDirs(blogDir string, posts []client.BlogPost) ([]string, error) {
+	expected := make(map[string]bool, len(posts))
+	for _, post := range posts {
+		expected[blogPostDirName(post)] = true
+	}
+
+	entries, err := os.ReadDir(blogDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for _, entry := range entries {
+		if entry.IsDir() && blogPostDirRe.MatchString(entry.Name()) && !expected[entry.Name()] {
+			stale = append(stale, entry.Name())
+		}
+	}
+	return stale, nil
+}
+
+// staleAttachmentFiles returns files in attachmentsDir that belong to none of
+// the listed attachments, along with their metadata sidecars
+func staleAttachmentFiles(attachmentsDir string, attachments []client.Attachment) ([]string, error) {
+	expected := make(map[string]bool, 2*len(attachments))
+	for _, name := range attachmentFileNames(attachments) {
+		expected[name] = true
+		expected[name+".json"] = true
+	}
+
+	entries, err := os.ReadDir(attachmentsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for _, entry := range entries {
+		// Skip in-progress temporary files
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if !expected[entry.Name()] {
+			stale = append(stale, entry.Name())
+		}
+	}
+	return stale, nil
+}
+
+// handleStale applies the deletion policy to stale files or directories under dir.
+// It reports whether they were removed from dir.
+func (cl *Cloner) handleStale(dir string, names []string, kind string) bool {
+	if len(names) == 0 {
+		return false
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		rel, err := filepath.Rel(cl.outputDir, path)
+		if err != nil {
+			rel = path
+		}
+
+		switch cl.Deletions {
+		case DeletionRemove:
+			if err := os.RemoveAll(path); err != nil {
+				cl.logf("    Warning: Failed to remove stale %s %s: %v\n", kind, rel, err)
+				continue
+			}
+			cl.logf("    Removed stale %s: %s\n", kind, rel)
+		case DeletionTombstone:
+			tombstone := filepath.Join(cl.outputDir, tombstoneDirName, cl.tombstoneStamp(), rel)
+			if err := os.MkdirAll(filepath.Dir(tombstone), 0755); err != nil {
+				cl.logf("    Warning: Failed to tombstone stale %s %s: %v\n", kind, rel, err)
+				continue
+			}
+			if err := os.Rename(path, tombstone); err != nil {
+				cl.logf("    Warning: Failed to tombstone stale %s %s: %v\n", kind, rel, err)
+				continue
+			}
+			cl.logf("    Tombstoned stale %s: %s\n", kind, rel)
+		default:
+			cl.logf("    Stale %s (deleted or renamed upstream): %s\n", kind, rel)
+		}
+	}
+	return cl.Deletions == DeletionRemove || cl.Deletions == DeletionTombstone
+}
+
+// pruneAttachments applies the deletion policy to files in attachmentsDir
+// that belong to none of the listed attachments
+func (cl *Cloner) pruneAttachments(attachmentsDir string, attachments []client.Attachment) {
+	stale, err := staleAttachmentFiles(attachmentsDir, attachments)
+	if err != nil {
+		cl.logf("    Warning: Failed to check for deleted attachments: %v\n", err)
+		return
+	}
+	cl.handleStale(attachmentsDir, stale, "attachment")
+}
+
+// tombstoneStamp names this run's tombstone directory after the time it started
+func (cl *Cloner) tombstoneStamp() string {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if cl.started.IsZero() {
+		cl.started = time.Now()
+	}
+	return cl.started.UTC().Format("20060102T150405Z")
+}
+
+// prune drops sync state for items that no longer exist upstream
+func (s *spaceState) prune(items map[string]*contentState, live map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range items {
+		if !live[id] {
+			delete(items, id)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package clone
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+// Filters selects which spaces, pages and blog posts are cloned.
+// Empty fields match everything.
+type Filters struct {
+	IncludeSpaces []string       // Space keys or glob patterns (e.g. "ENG-*") to clone
+	ExcludeSpaces []string       // Space keys or glob patterns to skip
+	SpaceTypes    []string       // Space types to clone, e.g. "global"
+	IncludeTitle  *regexp.Regexp // Only clone content whose title matches
+	ExcludeTitle  *regexp.Regexp // Skip content whose title matches
+	IncludeLabels []string       // Only clone content with at least one of these labels
+	ExcludeLabels []string       // Skip content with any of these labels
+	SubtreeRoots  []string       // Only clone these pages and the pages below them
+	ModifiedAfter time.Time      // Only clone content last modified after this time
+}
+
+// spaceQuery returns the space filters the v2 spaces endpoint can apply itself
+func (f *Filters) spaceQuery() url.Values {
+	query := url.Values{}
+	if len(f.IncludeSpaces) > 0 && !hasGlob(f.IncludeSpaces) {
+		keys := make([]string, len(f.IncludeSpaces))
+		for i, key := range f.IncludeSpaces {
+			keys[i] = spaceKey(key)
+		}
+		query.Set("keys", strings.Join(keys, ","))
+	}
+	if len(f.SpaceTypes) == 1 {
+		query.Set("type", f.SpaceTypes[0])
+	}
+	return query
+}
+
+// matchSpace reports whether a space passes the space filters
+func (f *Filters) matchSpace(space client.Space) bool {
+	if len(f.IncludeSpaces) > 0 && !matchSpaceKey(f.IncludeSpaces, space.Key) {
+		return false
+	}
+	if matchSpaceKey(f.ExcludeSpaces, space.Key) {
+		return false
+	}
+	if len(f.SpaceTypes) > 0 && !containsFold(f.SpaceTypes, space.Type) {
+		return false
+	}
+	return true
+}
+
+// matchContent reports whether a page or blog post passes the title and date filters
+func (f *Filters) matchContent(title string, version *client.Version) bool {
+	if f.IncludeTitle != nil && !f.IncludeTitle.MatchString(title) {
+		return false
+	}
+	if f.ExcludeTitle != nil && f.ExcludeTitle.MatchString(title) {
+		return false
+	}
+	if !f.ModifiedAfter.IsZero() {
+		// Content with no known modification time can't be shown to be recent
+		if version == nil || !parseTime(version.When).After(f.ModifiedAfter) {
+			return false
+		}
+	}
+	return true
+}
+
+// filtersLabels reports whether labels have to be fetched to apply the filters
+func (f *Filters) filtersLabels() bool {
+	return len(f.IncludeLabels) > 0 || len(f.ExcludeLabels) > 0
+}
+
+// matchLabels reports whether content with these labels passes the label filters
+func (f *Filters) matchLabels(labels []string) bool {
+	if len(f.IncludeLabels) > 0 {
+		found := false
+		for _, label := range labels {
+			if containsFold(f.IncludeLabels, label) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, label := range labels {
+		if containsFold(f.ExcludeLabels, label) {
+			return false
+		}
+	}
+	return true
+}
+
+// inSubtree reports whether a page is one of the subtree roots or below one
+func (f *Filters) inSubtree(tree *pageTree, id string) bool {
+	if len(f.SubtreeRoots) == 0 {
+		return true
+	}
+	node, ok := tree.nodes[id]
+	if !ok {
+		return containsFold(f.SubtreeRoots, id)
+	}
+	for n := node; n != nil; n = n.parent {
+		if containsFold(f.SubtreeRoots, n.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGlob reports whether any pattern uses glob syntax
+func hasGlob(patterns []string) bool {
+	for _, p := range patterns {
+		if strings.ContainsAny(p, `*?[\`) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny reports whether s matches any of the glob patterns, ignoring case
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, err := path.Match(strings.ToUpper(p), strings.ToUpper(s)); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// spaceKey normalises a space key or pattern the way Confluence stores keys:
+// global space keys are upper case, while personal space keys ("~" and an
+// account ID) are case-sensitive and kept as they are
+func spaceKey(key string) string {
+	if strings.HasPrefix(key, "~") {
+		return key
+	}
+	return strings.ToUpper(key)
+}
+
+// matchSpaceKey reports whether a space key matches any of the key patterns
+func matchSpaceKey(patterns []string, key string) bool {
+	for _, p := range patterns {
+		if ok, err := path.Match(spaceKey(p), spaceKey(key)); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
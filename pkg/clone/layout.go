@@ -0,0 +1,132 @@
+package clone
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Layout controls how page directories are arranged under a space's pages directory
+type Layout string
+
+const (
+	// LayoutFlat puts every page directly under pages/
+	LayoutFlat Layout = "flat"
+	// LayoutNested puts each page's directory inside its parent's, mirroring the page tree
+	LayoutNested Layout = "nested"
+)
+
+// ParseLayout parses a layout name, accepting "" as the flat default
+func ParseLayout(s string) (Layout, error) {
+	switch Layout(strings.ToLower(strings.TrimSpace(s))) {
+	case "", LayoutFlat:
+		return LayoutFlat, nil
+	case LayoutNested:
+		return LayoutNested, nil
+	}
+	return "", fmt.Errorf("unknown layout %q (want %q or %q)", s, LayoutFlat, LayoutNested)
+}
+
+// pageDirRe matches a page directory name and captures the page ID
+var pageDirRe = regexp.MustCompile(`^([0-9]+)_`)
+
+// findPageDirs maps page IDs to their existing directories under pagesDir,
+// relative to it. Nested page directories are found at any depth.
+func findPageDirs(pagesDir string) (map[string]string, error) {
+	dirs := make(map[string]string)
+	err := filepath.WalkDir(pagesDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == pagesDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() || path == pagesDir {
+			return nil
+		}
+
+		m := pageDirRe.FindStringSubmatch(d.Name())
+		if m == nil {
+			// attachments/, versions/ and the like never contain pages
+			return filepath.SkipDir
+		}
+		if _, seen := dirs[m[1]]; !seen {
+			rel, err := filepath.Rel(pagesDir, path)
+			if err != nil {
+				return err
+			}
+			dirs[m[1]] = rel
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+// relocatePages moves existing page directories to where the tree now puts
+// them, so pages that were moved, renamed or switched between layouts keep
+// their downloaded attachments and history. Parents are handled before their
+// children, so a moved parent carries its subtree along and each child is then
+// placed individually. A page that can't be moved is reported and left where
+// it is, and the rest are still moved.
+func (cl *Cloner) relocatePages(tree *pageTree, pagesDir string) error {
+	existing, err := findPageDirs(pagesDir)
+	if err != nil {
+		return fmt.Errorf("failed to scan page directories: %w", err)
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+
+	type move struct{ from, to string }
+	var moves []move
+
+	// current applies earlier moves to a directory found by the scan
+	current := func(dir string) string {
+		for _, m := range moves {
+			if dir == m.from {
+				dir = m.to
+			} else if strings.HasPrefix(dir, m.from+string(filepath.Separator)) {
+				dir = m.to + dir[len(m.from):]
+			}
+		}
+		return dir
+	}
+
+	var walk func(nodes []*pageNode)
+	walk = func(nodes []*pageNode) {
+		for _, node := range nodes {
+			if dir, ok := existing[node.ID]; ok {
+				from := current(dir)
+				to := tree.dir(node.ID, node.Title)
+				if from != to {
+					if err := movePageDir(filepath.Join(pagesDir, from), filepath.Join(pagesDir, to)); err != nil {
+						cl.logf("  Warning: Failed to move page %s: %v\n", node.ID, err)
+					} else {
+						cl.logf("  Moved page %s: %s -> %s\n", node.ID, from, to)
+						moves = append(moves, move{from: from, to: to})
+					}
+				}
+			}
+			walk(node.Children)
+		}
+	}
+	walk(tree.Roots)
+	return nil
+}
+
+// movePageDir renames a page directory, refusing to overwrite an existing one
+func movePageDir(from, to string) error {
+	if _, err := os.Stat(to); err == nil {
+		return fmt.Errorf("cannot move %s: %s already exists", from, to)
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	if err := os.Rename(from, to); err != nil {
+		return fmt.Errorf("failed to move page directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package clone
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+// Limits on what is written under the output directory, in UTF-8 bytes, which
+// is never fewer than the UTF-16 units Windows and macOS count. Most file
+// systems allow 255 per name. Windows tools still expect whole paths under 260
+// characters, so page and blog post directories are kept short enough for a
+// page with ordinary attachment names to fit with room for the output directory;
+// only attachments with names near their limit go past it.
+const (
+	maxNameBytes           = 200                         // Any single file or directory name
+	maxPathBytes           = 350                         // A path below the output directory
+	maxSpaceDirBytes       = 40                          // A space's directory
+	maxAttachmentNameBytes = maxNameBytes - len(".json") // An attachment's file name, leaving room for its sidecar's .json
+	maxNestedTitleBytes    = 24                          // The title in each directory of a nested page layout
+)
+
+// containerDirBytes is how long the path from a space's pages/ or blogposts/
+// directory down to a page or blog post directory may be, leaving room above it
+// for the space and below it for an attachment's sidecar
+func containerDirBytes(container string) int {
+	return maxPathBytes - maxSpaceDirBytes - len("/"+container+"/") -
+		len("/attachments/") - maxAttachmentNameBytes - len(".json")
+}
+
+var (
+	pageDirBytes     = containerDirBytes("pages")
+	blogPostDirBytes = containerDirBytes("blogposts")
+)
+
+// sanitizeFilename makes a title safe to use as a file or directory name on
+// Windows, macOS and Linux, at most maxNameBytes long
+func sanitizeFilename(name string) string {
+	return safeName(name, maxNameBytes)
+}
+
+// safeName cleans name into a file name of at most maxBytes, keeping its
+// extension. Names Windows reserves for devices get an underscore after the
+// base name, and a leading dot, which would hide the file and clash with
+// temporary files, becomes an underscore.
+func safeName(name string, maxBytes int) string {
+	name = cleanName(name)
+	if strings.HasPrefix(name, ".") {
+		name = "_" + name[1:]
+	}
+	if base, ext, _ := strings.Cut(name, "."); windowsReserved(base) {
+		name = base + "_"
+		if ext != "" {
+			name += "." + ext
+		}
+	}
+	if name = truncateName(name, maxBytes); name == "" {
+		return "_"
+	}
+	return name
+}
+
+// cleanName normalizes text to NFC, so titles typed on macOS and Windows
+// compare equal, and replaces the characters file systems reject with
+// underscores. Surrounding spaces and trailing dots, which Windows drops, are
+// trimmed.
+func cleanName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, norm.NFC.String(s))
+	return strings.TrimRight(strings.TrimSpace(s), ". ")
+}
+
+// windowsReserved reports whether base is a device name Windows won't use
+// for a file, whatever its extension
+func windowsReserved(base string) bool {
+	base = strings.ToUpper(strings.TrimRight(base, " "))
+	switch base {
+	case "CON", "PRN", "AUX", "NUL":
+		return true
+	}
+	if len(base) > 3 && (strings.HasPrefix(base, "COM") || strings.HasPrefix(base, "LPT")) {
+		switch base[3:] {
+		case "0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "¹", "²", "³":
+			return true
+		}
+	}
+	return false
+}
+
+// truncateName shortens name to at most maxBytes, keeping a short extension
+// so the file still opens with the right application
+func truncateName(name string, maxBytes int) string {
+	if len(name) <= maxBytes {
+		return name
+	}
+	ext := filepath.Ext(name)
+	if len(ext) > 16 || len(ext) >= maxBytes/2 {
+		ext = ""
+	}
+	return truncateBytes(strings.TrimSuffix(name, ext), maxBytes-len(ext)) + ext
+}
+
+// truncateBytes cuts s to at most n bytes without splitting a UTF-8 sequence,
+// trimming the spaces and dots the cut leaves at the end
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	n = max(n, 0)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return strings.TrimRight(s[:n], ". ")
+}
+
+// spaceDirName names a space's directory after its key. Keys longer than
+// maxSpaceDirBytes, like those of some personal spaces, are shortened and
+// end in a hash of the full key so they stay unique.
+func spaceDirName(key string) string {
+	name := sanitizeFilename(key)
+	if len(name) <= maxSpaceDirBytes {
+		return name
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return fmt.Sprintf("%s~%08x", truncateBytes(name, maxSpaceDirBytes-9), h.Sum32())
+}
+
+// pageDirName names a page directory by ID and title so it stays unique and
+// readable, shortening the title to keep the name within maxBytes
+func pageDirName(id, title string, maxBytes int) string {
+	return id + "_" + truncateBytes(cleanName(title), maxBytes-len(id)-1)
+}
+
+// attachmentFileNames maps each attachment's ID to the file it is saved as in
+// its page's attachments directory. Titles that clean up to the same name, or
+// to another's sidecar, ignoring case as Windows and macOS do, are told apart
+// by attachment ID. The lowest ID keeps the plain name, so names stay put as
+// attachments are added.
+func attachmentFileNames(attachments []client.Attachment) map[string]string {
+	sorted := slices.Clone(attachments)
+	slices.SortFunc(sorted, func(a, b client.Attachment) int { return compareIDs(a.ID, b.ID) })
+
+	fold := cases.Fold()
+	names := make(map[string]string, len(sorted))
+	taken := make(map[string]bool, 2*len(sorted))
+	for _, attachment := range sorted {
+		plain := safeName(attachment.Title, maxAttachmentNameBytes)
+		name := plain
+		for n := 1; taken[fold.String(name)] || taken[fold.String(name+".json")]; n++ {
+			name = disambiguateName(plain, attachment.ID, n)
+		}
+		taken[fold.String(name)] = true
+		taken[fold.String(name+".json")] = true
+		names[attachment.ID] = name
+	}
+	return names
+}
+
+// disambiguateName adds an attachment ID, and after the first attempt a
+// counter, before a file name's extension
+func disambiguateName(name, id string, attempt int) string {
+	suffix := " (" + id + ")"
+	if attempt > 1 {
+		suffix = fmt.Sprintf(" (%s-%d)", id, attempt)
+	}
+	ext := filepath.Ext(name)
+	if len(ext) > 16 {
+		ext = ""
+	}
+	base := strings.TrimSuffix(name, ext)
+	return truncateBytes(base, maxAttachmentNameBytes-len(suffix)-len(ext)) + suffix + ext
+}
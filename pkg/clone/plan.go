@@ -0,0 +1,412 @@
+package clone
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+const (
+	// planFileName is the dry-run report, written to the output root
+	planFileName = "plan.json"
+	// planMetadataBytes is a rough size of each small JSON file a clone writes
+	// beside content: metadata.json, comments.json and attachment sidecars
+	planMetadataBytes = 1024
+)
+
+// Plan describes what a clone would fetch and write. Estimates assume a full
+// clone; incremental and resumed runs skip work and need less.
+type Plan struct {
+	GeneratedAt time.Time   `json:"generatedAt"`
+	Spaces      []PlanSpace `json:"spaces"`
+	Totals      PlanTotals  `json:"totals"`
+}
+
+// PlanSpace is the part of a plan in one space
+type PlanSpace struct {
+	ID                 string        `json:"id"`
+	Key                string        `json:"key"`
+	Name               string        `json:"name"`
+	Pages              []PlanContent `json:"pages"`
+	BlogPosts          []PlanContent `json:"blogPosts"`
+	EstimatedAPICalls  int           `json:"estimatedApiCalls"`
+	EstimatedDiskBytes int64         `json:"estimatedDiskBytes"`
+	Error              string        `json:"error,omitempty"` // Why the space couldn't be fully planned
+
+	listedPages     int // Pages and blog posts in the listings, which are paged through in full
+	listedBlogPosts int
+	users           map[string]bool // Account IDs behind the planned content
+}
+
+// PlanContent is a page or blog post a clone would fetch
+type PlanContent struct {
+	ID              string `json:"id"`
+	Title           string `json:"title"`
+	Status          string `json:"status"`
+	Version         int    `json:"version"`
+	Dir             string `json:"dir"`                          // Directory relative to the space directory
+	BodyBytes       *int   `json:"bodyBytes,omitempty"`          // Known only when the listing carried the body
+	Attachments     int    `json:"attachments"`                  // Attachments that would be downloaded
+	AttachmentBytes int64  `json:"attachmentBytes"`              // Their total size
+	Skipped         int    `json:"skippedAttachments,omitempty"` // Attachments the attachment filters leave out
+}
+
+// PlanTotals sums a plan across spaces
+type PlanTotals struct {
+	Spaces             int   `json:"spaces"`
+	Pages              int   `json:"pages"`
+	BlogPosts          int   `json:"blogPosts"`
+	Attachments        int   `json:"attachments"`
+	AttachmentBytes    int64 `json:"attachmentBytes"`
+	SkippedAttachments int   `json:"skippedAttachments"`
+	UnsizedBodies      int   `json:"unsizedBodies,omitempty"` // Bodies left out of the disk estimate
+	Users              int   `json:"users"`                   // People to resolve for metadata and frontmatter
+	EstimatedAPICalls  int   `json:"estimatedApiCalls"`
+	EstimatedDiskBytes int64 `json:"estimatedDiskBytes"`
+}
+
+// Plan works out what Clone would do without writing any content
+func (cl *Cloner) Plan() (*Plan, error) {
+	return cl.PlanContext(context.Background())
+}
+
+// PlanContext lists the spaces, pages, blog posts and attachments a clone
+// would fetch after filtering and sampling, and estimates the API calls and
+// disk space it would need. No content is written: only plan.json, and the
+// sample manifest when sampling, are saved to the output directory.
+func (cl *Cloner) PlanContext(ctx context.Context) (*Plan, error) {
+	cl.planning = true
+	defer func() { cl.planning = false }()
+
+	if err := os.MkdirAll(cl.outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := cl.startSample(); err != nil {
+		return nil, err
+	}
+
+	spaces, err := cl.selectSpaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Plan spaces concurrently, with the same workers a clone would use
+	cl.startWorkers()
+	spaceCtx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	plan := &Plan{GeneratedAt: time.Now().UTC(), Spaces: make([]PlanSpace, len(spaces))}
+	err = cl.forEachConcurrent(spaceCtx, cl.spacePool, len(spaces), func(i int) {
+		space := spaces[i]
+		cl.logf("[%d/%d] Planning space: %s (%s)\n", i+1, len(spaces), space.Name, space.Key)
+		planned, err := cl.planSpace(spaceCtx, space)
+		if err != nil && spaceCtx.Err() == nil {
+			if client.IsUnauthorized(err) {
+				stop(fmt.Errorf("authentication failed while planning space %s: %w", space.Key, err))
+			}
+			cl.logf("  Warning: Failed to plan space %s: %v\n", space.Key, err)
+			planned.Error = err.Error()
+		}
+		plan.Spaces[i] = planned
+	})
+	if err != nil {
+		return nil, context.Cause(spaceCtx)
+	}
+
+	users := make(map[string]bool)
+	for _, space := range plan.Spaces {
+		for id := range space.users {
+			users[id] = true
+		}
+	}
+	cl.estimate(plan, len(users))
+
+	if err := saveJSON(filepath.Join(cl.outputDir, planFileName), plan); err != nil {
+		return nil, fmt.Errorf("failed to save plan: %w", err)
+	}
+	if err := cl.sampled.save(cl.outputDir); err != nil {
+		cl.logf("Warning: %v\n", err)
+	}
+
+	printPlan(plan)
+	cl.logf("Plan written to %s\n", filepath.Join(cl.outputDir, planFileName))
+	return plan, nil
+}
+
+// planSpace lists the pages, blog posts and attachments a clone of one space
+// would fetch, and the people behind them
+func (cl *Cloner) planSpace(ctx context.Context, space client.Space) (PlanSpace, error) {
+	planned := PlanSpace{ID: space.ID, Key: space.Key, Name: space.Name, users: make(map[string]bool)}
+
+	pages, tree, err := cl.selectPages(ctx, space)
+	if err != nil {
+		return planned, err
+	}
+	planned.listedPages = len(tree.nodes)
+
+	planned.Pages = make([]PlanContent, len(pages))
+	keep := make([]bool, len(pages))
+	err = cl.forEachConcurrent(ctx, cl.pagePool, len(pages), func(j int) {
+		page := pages[j]
+		planned.Pages[j] = PlanContent{
+			ID:      page.ID,
+			Title:   page.Title,
+			Status:  page.Status,
+			Version: versionNumber(page.Version),
+			Dir:     filepath.Join("pages", tree.dir(page.ID, page.Title)),
+		}
+		if page.HasBody() {
+			size := len(page.Body.Storage.Value)
+			planned.Pages[j].BodyBytes = &size
+		}
+		keep[j] = cl.planContent(ctx, &planned.Pages[j], pageContent{page})
+	})
+	if err != nil {
+		return planned, err
+	}
+	planned.Pages = keepPlanned(planned.Pages, keep)
+	for j, page := range pages {
+		if keep[j] {
+			addUsers(planned.users, page.AuthorID, page.OwnerID, page.Version)
+		}
+	}
+
+	// Blog posts sit outside the page tree, so a subtree export has none
+	if len(cl.Filters.SubtreeRoots) > 0 {
+		return planned, nil
+	}
+
+	current, posts, err := cl.selectBlogPosts(ctx, space)
+	if err != nil {
+		return planned, err
+	}
+	planned.listedBlogPosts = len(posts)
+
+	planned.BlogPosts = make([]PlanContent, len(current))
+	keep = make([]bool, len(current))
+	err = cl.forEachConcurrent(ctx, cl.pagePool, len(current), func(j int) {
+		post := current[j]
+		planned.BlogPosts[j] = PlanContent{
+			ID:      post.ID,
+			Title:   post.Title,
+			Status:  post.Status,
+			Version: versionNumber(post.Version),
+			Dir:     filepath.Join("blogposts", blogPostDirName(post)),
+		}
+		if post.HasBody() {
+			size := len(post.Body.Storage.Value)
+			planned.BlogPosts[j].BodyBytes = &size
+		}
+		keep[j] = cl.planContent(ctx, &planned.BlogPosts[j], blogPostContent{post})
+	})
+	if err != nil {
+		return planned, err
+	}
+	planned.BlogPosts = keepPlanned(planned.BlogPosts, keep)
+	for j, post := range current {
+		if keep[j] {
+			addUsers(planned.users, post.AuthorID, "", post.Version)
+		}
+	}
+
+	return planned, nil
+}
+
+// planContent counts a page's or blog post's attachments, reporting false if
+// the label filters exclude it
+func (cl *Cloner) planContent(ctx context.Context, item *PlanContent, c content) bool {
+	if cl.Filters.filtersLabels() {
+		labels, err := c.labels(ctx, cl.client)
+		if err != nil {
+			if ctx.Err() == nil {
+				cl.logf("    Warning: Failed to get labels for %s: %v\n", item.Title, err)
+			}
+			return true
+		}
+		if !cl.Filters.matchLabels(client.LabelNames(labels)) {
+			return false
+		}
+	}
+
+	attachments, err := c.attachments(ctx, cl.client)
+	if err != nil {
+		if ctx.Err() == nil {
+			cl.logf("    Warning: Failed to get attachments for %s: %v\n", item.Title, err)
+		}
+		return true
+	}
+	for _, attachment := range attachments {
+		if cl.AttachmentFilters.skipReason(attachment) != "" {
+			item.Skipped++
+			continue
+		}
+		item.Attachments++
+		item.AttachmentBytes += attachment.FileSize
+	}
+	return true
+}
+
+// keepPlanned drops the items the label filters excluded
+func keepPlanned(items []PlanContent, keep []bool) []PlanContent {
+	kept := items[:0]
+	for i, item := range items {
+		if keep[i] {
+			kept = append(kept, item)
+		}
+	}
+	return slices.Clip(kept)
+}
+
+// addUsers records the account IDs a clone would resolve for one item
+func addUsers(users map[string]bool, authorID, ownerID string, version *client.Version) {
+	for _, id := range []string{authorID, ownerID} {
+		if id != "" {
+			users[id] = true
+		}
+	}
+	if version != nil && version.AuthorID != "" {
+		users[version.AuthorID] = true
+	}
+}
+
+// estimate fills in the plan's totals and the API calls and disk space each
+// space would need. Comment replies and the pages of long comment listings
+// aren't known up front, so the call estimate is a lower bound.
+func (cl *Cloner) estimate(plan *Plan, users int) {
+	pageSize := cl.client.PageSize()
+	listCalls := func(n int) int {
+		return max(1, (n+pageSize-1)/pageSize)
+	}
+
+	// One space listing, plus one lookup per person
+	plan.Totals = PlanTotals{Users: users, EstimatedAPICalls: 1 + users}
+	totals := &plan.Totals
+
+	for i := range plan.Spaces {
+		space := &plan.Spaces[i]
+		// Space labels and the page listing, plus space.json, tree.json and sync-state.json
+		space.EstimatedAPICalls = 1 + listCalls(space.listedPages)
+		space.EstimatedDiskBytes = 3 * planMetadataBytes
+		if len(cl.Filters.SubtreeRoots) == 0 {
+			space.EstimatedAPICalls += listCalls(space.listedBlogPosts)
+		}
+
+		for _, item := range slices.Concat(space.Pages, space.BlogPosts) {
+			calls, bytes := cl.estimateContent(item, listCalls)
+			space.EstimatedAPICalls += calls
+			space.EstimatedDiskBytes += bytes
+		}
+
+		totals.Spaces++
+		totals.Pages += len(space.Pages)
+		totals.BlogPosts += len(space.BlogPosts)
+		for _, item := range slices.Concat(space.Pages, space.BlogPosts) {
+			totals.Attachments += item.Attachments
+			totals.AttachmentBytes += item.AttachmentBytes
+			totals.SkippedAttachments += item.Skipped
+			if item.BodyBytes == nil {
+				totals.UnsizedBodies++
+			}
+		}
+		totals.EstimatedAPICalls += space.EstimatedAPICalls
+		totals.EstimatedDiskBytes += space.EstimatedDiskBytes
+	}
+}
+
+// estimateContent returns the API calls and bytes written to clone one page or
+// blog post with its attachments, comments and version history
+func (cl *Cloner) estimateContent(item PlanContent, listCalls func(int) int) (int, int64) {
+	// Its labels, the attachment listing and each download, plus the full
+	// content when bodies don't come with the listing
+	calls := 1 + listCalls(item.Attachments+item.Skipped) + item.Attachments
+	if !cl.listBodies() {
+		calls++
+	}
+
+	// metadata.json, content.html and content.md, then each attachment and its
+	// sidecar. Bodies count only when their size is known.
+	body := int64(0)
+	if item.BodyBytes != nil {
+		body = int64(*item.BodyBytes)
+	}
+	bytes := planMetadataBytes + body
+	if cl.exportMarkdown {
+		bytes += body
+	}
+	bytes += item.AttachmentBytes + int64(item.Attachments)*planMetadataBytes
+	if cl.AttachmentFilters.MetadataOnly {
+		bytes += int64(item.Skipped) * planMetadataBytes
+	}
+
+	if cl.ExportComments {
+		// The comment listing, replies included
+		calls++
+		bytes += planMetadataBytes
+	}
+	if cl.VersionHistory != 0 {
+		saved := item.Version
+		if cl.VersionHistory > 0 {
+			saved = min(saved, cl.VersionHistory)
+		}
+		// The version listing, then every saved version but the current one
+		calls += 1 + max(saved-1, 0)
+		bytes += int64(saved) * (body + planMetadataBytes)
+	}
+	return calls, bytes
+}
+
+// printPlan prints a human-readable summary of a plan
+func printPlan(plan *Plan) {
+	fmt.Println()
+	fmt.Println("Dry run plan")
+	fmt.Println("============")
+	for _, space := range plan.Spaces {
+		attachments, attachmentBytes := 0, int64(0)
+		for _, item := range slices.Concat(space.Pages, space.BlogPosts) {
+			attachments += item.Attachments
+			attachmentBytes += item.AttachmentBytes
+		}
+		fmt.Printf("%s (%s): %d page(s), %d blog post(s), %d attachment(s) totalling %s\n",
+			space.Name, space.Key, len(space.Pages), len(space.BlogPosts), attachments, formatBytes(attachmentBytes))
+		if space.Error != "" {
+			fmt.Printf("  Incomplete: %s\n", space.Error)
+		}
+	}
+
+	totals := plan.Totals
+	fmt.Println()
+	fmt.Printf("Spaces:      %d\n", totals.Spaces)
+	fmt.Printf("Pages:       %d\n", totals.Pages)
+	fmt.Printf("Blog posts:  %d\n", totals.BlogPosts)
+	fmt.Printf("Attachments: %d (%s)\n", totals.Attachments, formatBytes(totals.AttachmentBytes))
+	if totals.SkippedAttachments > 0 {
+		fmt.Printf("Skipped:     %d attachment(s) left out by attachment filters\n", totals.SkippedAttachments)
+	}
+	fmt.Printf("People:      %d\n", totals.Users)
+	fmt.Printf("Estimated API calls:  at least %d\n", totals.EstimatedAPICalls)
+	if totals.UnsizedBodies > 0 {
+		fmt.Printf("Estimated disk usage: about %s, plus %d body(ies) of unknown size\n",
+			formatBytes(totals.EstimatedDiskBytes), totals.UnsizedBodies)
+	} else {
+		fmt.Printf("Estimated disk usage: about %s\n", formatBytes(totals.EstimatedDiskBytes))
+	}
+	fmt.Println()
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. "1.5 MiB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
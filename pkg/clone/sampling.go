@@ -0,0 +1,493 @@
+package clone
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+// SampleStrategy decides which pages and blog posts a sample picks
+type SampleStrategy string
+
+const (
+	// SampleRandom picks uniformly at random
+	SampleRandom SampleStrategy = "random"
+	// SampleRecent picks the most recently modified first
+	SampleRecent SampleStrategy = "recent"
+	// SampleLargest picks the largest bodies first
+	SampleLargest SampleStrategy = "largest"
+	// SampleOldest picks the earliest created first
+	SampleOldest SampleStrategy = "oldest"
+	// SampleStratifiedPerSpace splits the page budget evenly across the
+	// sampled spaces and picks at random within each
+	SampleStratifiedPerSpace SampleStrategy = "stratified-per-space"
+	// SampleStratifiedSections picks at random, spread evenly across each space's top-level sections
+	SampleStratifiedSections SampleStrategy = "stratified-sections"
+)
+
+// sampleManifestFileName records the sample a run picked, kept at the output root
+const sampleManifestFileName = "sample-manifest.json"
+
+// ParseSampleStrategy parses a sample strategy name, accepting "" as the random default
+func ParseSampleStrategy(s string) (SampleStrategy, error) {
+	switch st := SampleStrategy(strings.ToLower(strings.TrimSpace(s))); st {
+	case "":
+		return SampleRandom, nil
+	case SampleRandom, SampleRecent, SampleLargest, SampleOldest, SampleStratifiedPerSpace, SampleStratifiedSections:
+		return st, nil
+	}
+	return "", fmt.Errorf("unknown sample strategy %q (want %q, %q, %q, %q, %q or %q)",
+		s, SampleRandom, SampleRecent, SampleLargest, SampleOldest, SampleStratifiedPerSpace, SampleStratifiedSections)
+}
+
+// sampleManifest records the spaces, pages and blog posts a sample picked, so
+// a later run can clone exactly the same content
+type sampleManifest struct {
+	mu sync.Mutex
+
+	CreatedAt    time.Time                `json:"createdAt"`
+	Seed         int64                    `json:"seed"`
+	Strategy     SampleStrategy           `json:"strategy"`
+	SampleSpaces int                      `json:"sampleSpaces,omitempty"`
+	SamplePages  int                      `json:"samplePages,omitempty"`
+	Spaces       map[string]*sampledSpace `json:"spaces"` // space ID -> sample
+}
+
+// sampledSpace is the part of a sample in one space. Null page or blog post
+// lists mean that content wasn't sampled, so all of it is cloned.
+type sampledSpace struct {
+	Key       string   `json:"key"`
+	PageQuota int      `json:"pageQuota,omitempty"` // Pages and blog posts this space's share of a stratified-per-space budget allows
+	Pages     []string `json:"pages"`
+	BlogPosts []string `json:"blogPosts"`
+}
+
+// loadSampleManifest reads a manifest written by an earlier sampled run
+func loadSampleManifest(path string) (*sampleManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m sampleManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid sample manifest %s: %w", path, err)
+	}
+	if m.Spaces == nil {
+		m.Spaces = make(map[string]*sampledSpace)
+	}
+	return &m, nil
+}
+
+// space returns the sample recorded for a space, or nil if there is none
+func (m *sampleManifest) space(id string) *sampledSpace {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Spaces[id]
+}
+
+// record notes the space, page or blog post IDs a sample picked
+func (m *sampleManifest) record(space client.Space, pages, blogPosts []string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.Spaces[space.ID]
+	if !ok {
+		s = &sampledSpace{Key: space.Key}
+		m.Spaces[space.ID] = s
+	}
+	if pages != nil {
+		s.Pages = pages
+	}
+	if blogPosts != nil {
+		s.BlogPosts = blogPosts
+	}
+}
+
+// setQuota notes a space's share of a stratified-per-space page budget
+func (m *sampleManifest) setQuota(space client.Space, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.Spaces[space.ID]; ok {
+		s.PageQuota = n
+	}
+}
+
+// save writes the manifest to the output root
+func (m *sampleManifest) save(outputDir string) error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := saveJSON(filepath.Join(outputDir, sampleManifestFileName), m); err != nil {
+		return fmt.Errorf("failed to save sample manifest: %w", err)
+	}
+	return nil
+}
+
+// sampling reports whether this run clones a sample rather than everything
+func (cl *Cloner) sampling() bool {
+	return cl.SampleSpaces > 0 || cl.SamplePages > 0 || cl.replay != nil
+}
+
+// startSample loads the manifest being replayed and starts this run's
+// manifest, choosing a seed if none was given
+func (cl *Cloner) startSample() error {
+	if cl.SampleReplay != "" {
+		replay, err := loadSampleManifest(cl.SampleReplay)
+		if err != nil {
+			return fmt.Errorf("failed to load sample manifest: %w", err)
+		}
+		cl.replay = replay
+	}
+	if !cl.sampling() {
+		return nil
+	}
+
+	cl.sampled = &sampleManifest{
+		CreatedAt:    time.Now().UTC(),
+		Seed:         cl.SampleSeed,
+		Strategy:     cl.SampleStrategy,
+		SampleSpaces: cl.SampleSpaces,
+		SamplePages:  cl.SamplePages,
+		Spaces:       make(map[string]*sampledSpace),
+	}
+	if cl.sampled.Strategy == "" {
+		cl.sampled.Strategy = SampleRandom
+	}
+	switch {
+	case cl.replay != nil:
+		cl.sampled.Seed = cl.replay.Seed
+		cl.sampled.Strategy = cl.replay.Strategy
+		cl.sampled.SampleSpaces = cl.replay.SampleSpaces
+		cl.sampled.SamplePages = cl.replay.SamplePages
+		cl.logf("Replaying sample from %s\n", cl.SampleReplay)
+	case cl.sampled.Seed == 0:
+		cl.sampled.Seed = time.Now().UnixNano()
+		cl.logf("Sampling with strategy %s and seed %d\n", cl.sampled.Strategy, cl.sampled.Seed)
+	default:
+		cl.logf("Sampling with strategy %s and seed %d\n", cl.sampled.Strategy, cl.sampled.Seed)
+	}
+	return nil
+}
+
+// sampleRand returns a random source for one sampling decision. It is derived
+// from the seed and the scope, so each space's sample doesn't depend on which
+// other spaces were picked or the order they were cloned in.
+func (cl *Cloner) sampleRand(scope string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(scope))
+	return rand.New(rand.NewSource(cl.sampled.Seed ^ int64(h.Sum64())))
+}
+
+// sampleSpaces picks the spaces to clone, replaying the manifest if there is one
+func (cl *Cloner) sampleSpaces(spaces []client.Space) []client.Space {
+	switch {
+	case cl.replay != nil:
+		picked := slices.DeleteFunc(spaces, func(space client.Space) bool {
+			return cl.replay.space(space.ID) == nil
+		})
+		if missing := len(cl.replay.Spaces) - len(picked); missing > 0 {
+			cl.logf("Warning: %d sampled space(s) were not found\n", missing)
+		}
+		spaces = picked
+	case cl.SampleSpaces > 0 && len(spaces) > cl.SampleSpaces:
+		cl.logf("Sampling %d of %d spaces...\n", cl.SampleSpaces, len(spaces))
+		items := make([]sampleItem, len(spaces))
+		for i, space := range spaces {
+			items[i] = sampleItem{id: space.ID}
+		}
+		// Spaces carry nothing to rank them by, so every strategy picks them at random
+		spaces = pickSample(spaces, items, cl.SampleSpaces, SampleRandom, cl.sampleRand("spaces"))
+	}
+
+	for _, space := range spaces {
+		cl.sampled.record(space, nil, nil)
+	}
+	if cl.replay == nil && cl.SamplePages > 0 && cl.sampled.Strategy == SampleStratifiedPerSpace {
+		cl.splitPageQuota(spaces)
+	}
+	return spaces
+}
+
+// splitPageQuota shares the page budget evenly between the sampled spaces.
+// Spaces that get one more than the rest, when it doesn't divide evenly, are
+// picked at random from the seed.
+func (cl *Cloner) splitPageQuota(spaces []client.Space) {
+	if len(spaces) == 0 {
+		return
+	}
+	order := slices.Clone(spaces)
+	slices.SortFunc(order, func(a, b client.Space) int { return compareIDs(a.ID, b.ID) })
+	rng := cl.sampleRand("quotas")
+	rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+	share, extra := cl.SamplePages/len(order), cl.SamplePages%len(order)
+	for i, space := range order {
+		n := share
+		if i < extra {
+			n++
+		}
+		cl.sampled.setQuota(space, n)
+	}
+}
+
+// pageQuota returns how many pages, and how many blog posts, a sample takes
+// from a space: its share of the budget for stratified-per-space samples, and
+// the whole budget otherwise
+func (cl *Cloner) pageQuota(space client.Space) int {
+	if cl.sampled.Strategy == SampleStratifiedPerSpace {
+		if s := cl.sampled.space(space.ID); s != nil {
+			return s.PageQuota
+		}
+	}
+	return cl.SamplePages
+}
+
+// samplePages picks the pages to clone in a space
+func (cl *Cloner) samplePages(space client.Space, pages []client.Page, tree *pageTree) []client.Page {
+	if cl.replay != nil {
+		if s := cl.replay.space(space.ID); s != nil && s.Pages != nil {
+			var missing int
+			pages, missing = replaySample(pages, s.Pages, func(page client.Page) string { return page.ID })
+			if missing > 0 {
+				cl.logf("  Warning: %d sampled page(s) were not found\n", missing)
+			}
+			cl.sampled.record(space, sampleIDs(pages, func(page client.Page) string { return page.ID }), nil)
+		}
+		return pages
+	}
+	if cl.SamplePages <= 0 {
+		return pages
+	}
+
+	if n := cl.pageQuota(space); len(pages) > n {
+		cl.logf("  Sampling %d of %d pages...\n", n, len(pages))
+		items := make([]sampleItem, len(pages))
+		for i, page := range pages {
+			items[i] = sampleItem{
+				id:       page.ID,
+				created:  parseTime(page.CreatedAt),
+				modified: modifiedTime(page.Version),
+				section:  tree.section(page.ID),
+			}
+			if page.HasBody() {
+				items[i].size = len(page.Body.Storage.Value)
+			}
+		}
+		pages = pickSample(pages, items, n, cl.sampled.Strategy, cl.sampleRand("pages:"+space.ID))
+	}
+	cl.sampled.record(space, sampleIDs(pages, func(page client.Page) string { return page.ID }), nil)
+	return pages
+}
+
+// sampleBlogPosts picks the blog posts to clone in a space, with the same quota as pages
+func (cl *Cloner) sampleBlogPosts(space client.Space, posts []client.BlogPost) []client.BlogPost {
+	if cl.replay != nil {
+		if s := cl.replay.space(space.ID); s != nil && s.BlogPosts != nil {
+			var missing int
+			posts, missing = replaySample(posts, s.BlogPosts, func(post client.BlogPost) string { return post.ID })
+			if missing > 0 {
+				cl.logf("  Warning: %d sampled blog post(s) were not found\n", missing)
+			}
+			cl.sampled.record(space, nil, sampleIDs(posts, func(post client.BlogPost) string { return post.ID }))
+		}
+		return posts
+	}
+	if cl.SamplePages <= 0 {
+		return posts
+	}
+
+	if n := cl.pageQuota(space); len(posts) > n {
+		cl.logf("  Sampling %d of %d blog posts...\n", n, len(posts))
+		items := make([]sampleItem, len(posts))
+		for i, post := range posts {
+			items[i] = sampleItem{
+				id:       post.ID,
+				created:  parseTime(post.CreatedAt),
+				modified: modifiedTime(post.Version),
+			}
+			if post.HasBody() {
+				items[i].size = len(post.Body.Storage.Value)
+			}
+		}
+		posts = pickSample(posts, items, n, cl.sampled.Strategy, cl.sampleRand("blogposts:"+space.ID))
+	}
+	cl.sampled.record(space, nil, sampleIDs(posts, func(post client.BlogPost) string { return post.ID }))
+	return posts
+}
+
+// sampleBodies reports whether content listings need bodies to rank by size
+func (cl *Cloner) sampleBodies() bool {
+	return cl.replay == nil && cl.SamplePages > 0 && cl.sampled != nil && cl.sampled.Strategy == SampleLargest
+}
+
+// listBodies reports whether pages and blog posts are listed with their
+// bodies, rather than fetched one by one. A page sample is cheaper to fetch
+// individually than every body in the space, unless it's ranked by size.
+func (cl *Cloner) listBodies() bool {
+	if cl.sampleBodies() {
+		return true
+	}
+	return cl.SamplePages <= 0 && cl.replay == nil
+}
+
+// listSpaceBodies reports whether a space's pages and blog posts are listed
+// with their bodies. An incremental run over a space synced before fetches
+// only what changed, which costs less than every body in the space, and a dry
+// run needs bodies only to rank a sample by size.
+func (cl *Cloner) listSpaceBodies(space client.Space) bool {
+	switch {
+	case cl.sampleBodies():
+		return true
+	case cl.planning || !cl.listBodies():
+		return false
+	}
+	return !cl.Incremental || !fileExists(filepath.Join(cl.outputDir, spaceDirName(space.Key), stateFileName))
+}
+
+// sampleItem is what the sample strategies know about a space, page or blog post
+type sampleItem struct {
+	id       string
+	created  time.Time
+	modified time.Time
+	size     int
+	section  string // top-level page the item sits under, for stratified-sections samples
+}
+
+// pickSample returns up to n of items, chosen by strategy, in their original
+// order. Choices depend only on the items and rng, never on listing order.
+func pickSample[T any](all []T, items []sampleItem, n int, strategy SampleStrategy, rng *rand.Rand) []T {
+	if len(all) <= n {
+		return all
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int { return compareIDs(items[a].id, items[b].id) })
+
+	switch strategy {
+	case SampleRecent:
+		slices.SortStableFunc(order, func(a, b int) int { return items[b].modified.Compare(items[a].modified) })
+	case SampleOldest:
+		// Items with no creation date go last rather than first
+		slices.SortStableFunc(order, func(a, b int) int {
+			ca, cb := items[a].created, items[b].created
+			if ca.IsZero() != cb.IsZero() {
+				return compareBool(ca.IsZero(), cb.IsZero())
+			}
+			return ca.Compare(cb)
+		})
+	case SampleLargest:
+		slices.SortStableFunc(order, func(a, b int) int { return items[b].size - items[a].size })
+	case SampleStratifiedSections:
+		rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		order = interleaveSections(order, items)
+	default:
+		rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+
+	picked := order[:n]
+	slices.Sort(picked)
+	sampled := make([]T, 0, n)
+	for _, i := range picked {
+		sampled = append(sampled, all[i])
+	}
+	return sampled
+}
+
+// interleaveSections reorders items so the first of every section comes
+// first, then the second of each, and so on, keeping each section's order
+func interleaveSections(order []int, items []sampleItem) []int {
+	var sections []string
+	bySection := make(map[string][]int)
+	for _, i := range order {
+		section := items[i].section
+		if _, ok := bySection[section]; !ok {
+			sections = append(sections, section)
+		}
+		bySection[section] = append(bySection[section], i)
+	}
+	slices.SortFunc(sections, compareIDs)
+
+	interleaved := make([]int, 0, len(order))
+	for round := 0; len(interleaved) < len(order); round++ {
+		for _, section := range sections {
+			if round < len(bySection[section]) {
+				interleaved = append(interleaved, bySection[section][round])
+			}
+		}
+	}
+	return interleaved
+}
+
+// replaySample keeps the items whose IDs were recorded in a sample manifest,
+// and returns how many recorded IDs it didn't find
+func replaySample[T any](all []T, recorded []string, id func(T) string) ([]T, int) {
+	want := make(map[string]bool, len(recorded))
+	for _, r := range recorded {
+		want[r] = true
+	}
+	var picked []T
+	for _, item := range all {
+		if want[id(item)] {
+			picked = append(picked, item)
+		}
+	}
+	return picked, len(recorded) - len(picked)
+}
+
+// sampleIDs returns the IDs of items, never nil so an empty sample is still recorded
+func sampleIDs[T any](items []T, id func(T) string) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		out = append(out, id(item))
+	}
+	return out
+}
+
+// compareIDs orders numeric Confluence IDs numerically
+func compareIDs(a, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// compareBool orders false before true
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
+// modifiedTime returns when a version was created, or the zero time if unknown
+func modifiedTime(v *client.Version) time.Time {
+	if v == nil {
+		return time.Time{}
+	}
+	return parseTime(v.When)
+}
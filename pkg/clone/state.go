@@ -0,0 +1,123 @@
+package clone
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+// stateFileName is the per-space sync manifest, kept at the space root
+const stateFileName = "sync-state.json"
+
+// spaceState records which version of each page, blog post and attachment is
+// on disk, so later runs can skip content that hasn't changed
+type spaceState struct {
+	mu        sync.Mutex
+	Pages     map[string]*contentState `json:"pages"`
+	BlogPosts map[string]*contentState `json:"blogPosts"`
+}
+
+// contentState is the synced state of a page or blog post
+type contentState struct {
+	Title       string                     `json:"title"`
+	Version     int                        `json:"version"`
+	Dir         string                     `json:"dir"` // Relative to the space directory
+	Attachments map[string]attachmentState `json:"attachments,omitempty"`
+}
+
+// attachmentState is the synced state of an attachment
+type attachmentState struct {
+	Version  int    `json:"version"`
+	FileSize int64  `json:"fileSize"`
+	File     string `json:"file"`
+}
+
+func newSpaceState() *spaceState {
+	return &spaceState{
+		Pages:     make(map[string]*contentState),
+		BlogPosts: make(map[string]*contentState),
+	}
+}
+
+// loadSpaceState reads a space's sync manifest, returning an empty one if there is none yet
+func loadSpaceState(spaceDir string) (*spaceState, error) {
+	state := newSpaceState()
+	data, err := os.ReadFile(filepath.Join(spaceDir, stateFileName))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return newSpaceState(), fmt.Errorf("invalid %s: %w", stateFileName, err)
+	}
+	if state.Pages == nil {
+		state.Pages = make(map[string]*contentState)
+	}
+	if state.BlogPosts == nil {
+		state.BlogPosts = make(map[string]*contentState)
+	}
+	return state, nil
+}
+
+// save writes the manifest atomically
+func (s *spaceState) save(spaceDir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return saveJSON(filepath.Join(spaceDir, stateFileName), s)
+}
+
+// get returns a copy of the recorded state of an item, or nil
+func (s *spaceState) get(items map[string]*contentState, id string) *contentState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := items[id]; ok {
+		cp := *st
+		return &cp
+	}
+	return nil
+}
+
+// set records the state of an item
+func (s *spaceState) set(items map[string]*contentState, id string, st contentState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items[id] = &st
+}
+
+// attachments returns the recorded attachments of an item, nil-safe
+func (st *contentState) attachments() map[string]attachmentState {
+	if st == nil {
+		return nil
+	}
+	return st.Attachments
+}
+
+// unchanged reports whether content at version is already on disk in dir,
+// so an incremental run can skip fetching it again
+func (cl *Cloner) unchanged(prev *contentState, version *client.Version, dir string) bool {
+	if !cl.Incremental || prev == nil || version == nil || version.Number == 0 {
+		return false
+	}
+	if prev.Version != version.Number {
+		return false
+	}
+	return fileExists(filepath.Join(dir, "metadata.json"))
+}
+
+// newAttachmentState describes an attachment as it will be saved on disk as file
+func newAttachmentState(attachment client.Attachment, file string) attachmentState {
+	st := attachmentState{
+		FileSize: attachment.FileSize,
+		File:     file,
+	}
+	if attachment.Version != nil {
+		st.Version = attachment.Version.Number
+	}
+	return st
+}
@@ -0,0 +1,44 @@
+package clone
+
+import (
+	"net/url"
+)
+
+// includePersonalSpaces reports whether personal spaces are cloned, either by
+// policy or because the space type filter asks for them
+func (cl *Cloner) includePersonalSpaces() bool {
+	return cl.IncludePersonalSpaces || containsFold(cl.Filters.SpaceTypes, "personal")
+}
+
+// includeArchivedContent reports whether archived pages and blog posts are cloned
+func (cl *Cloner) includeArchivedContent() bool {
+	return cl.IncludeArchivedPages || containsFold(cl.ContentStatuses, "archived")
+}
+
+// contentQuery returns the status filter sent when listing pages and blog posts
+func (cl *Cloner) contentQuery() url.Values {
+	query := url.Values{}
+	for _, status := range cl.ContentStatuses {
+		query.Add("status", status)
+	}
+	return query
+}
+
+// completeListing reports whether content listings include every current and
+// archived item, so anything missing from them was really deleted upstream
+func (cl *Cloner) completeListing() bool {
+	if len(cl.ContentStatuses) == 0 {
+		return true
+	}
+	return containsFold(cl.ContentStatuses, "current") && containsFold(cl.ContentStatuses, "archived")
+}
+
+// fetchStatus returns the status to request when fetching a single item in the
+// given status. Current and archived content is returned by default.
+func fetchStatus(status string) string {
+	switch status {
+	case "", "current", "archived":
+		return ""
+	}
+	return status
+}
@@ -0,0 +1,161 @@
+package clone
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+	"github.com/nycmonkey/confluence-reader/pkg/markdown"
+)
+
+// pageNode is a page in a space's hierarchy
+type pageNode struct {
+	ID       string      `json:"id"`
+	Title    string      `json:"title"`
+	Status   string      `json:"status"`
+	ParentID string      `json:"parentId,omitempty"`
+	Children []*pageNode `json:"children,omitempty"`
+	position int
+	parent   *pageNode
+}
+
+// pageTree is the page hierarchy of a space, built from its page listing
+type pageTree struct {
+	Roots  []*pageNode `json:"pages"`
+	nodes  map[string]*pageNode
+	layout Layout
+}
+
+// buildPageTree arranges pages by ParentID. Pages whose parent isn't in the
+// listing (top-level pages, or parents we can't see) become roots, as does any
+// page whose parent link would close a cycle.
+func buildPageTree(pages []client.Page, layout Layout) *pageTree {
+	tree := &pageTree{nodes: make(map[string]*pageNode, len(pages)), layout: layout}
+	for _, page := range pages {
+		tree.nodes[page.ID] = &pageNode{
+			ID:       page.ID,
+			Title:    page.Title,
+			Status:   page.Status,
+			ParentID: page.ParentID,
+			position: page.Position,
+		}
+	}
+
+	// Parent links accepted so far; always acyclic
+	parents := make(map[string]string, len(pages))
+	for _, page := range pages {
+		node := tree.nodes[page.ID]
+		parent, ok := tree.nodes[page.ParentID]
+		if !ok || closesCycle(parents, page.ID, page.ParentID) {
+			tree.Roots = append(tree.Roots, node)
+			continue
+		}
+		parents[page.ID] = parent.ID
+		node.parent = parent
+		parent.Children = append(parent.Children, node)
+	}
+
+	sortNodes(tree.Roots)
+	for _, node := range tree.nodes {
+		sortNodes(node.Children)
+	}
+	return tree
+}
+
+// closesCycle reports whether linking id under parentID would make id its own ancestor
+func closesCycle(parents map[string]string, id, parentID string) bool {
+	for p := parentID; p != ""; p = parents[p] {
+		if p == id {
+			return true
+		}
+	}
+	return false
+}
+
+// sortNodes orders siblings as Confluence shows them, falling back to title
+func sortNodes(nodes []*pageNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].position != nodes[j].position {
+			return nodes[i].position < nodes[j].position
+		}
+		return nodes[i].Title < nodes[j].Title
+	})
+}
+
+// ids returns the set of page IDs in the tree
+func (t *pageTree) ids() map[string]bool {
+	ids := make(map[string]bool, len(t.nodes))
+	for id := range t.nodes {
+		ids[id] = true
+	}
+	return ids
+}
+
+// section returns the top-level section a page sits in: the ancestor just
+// below its root page, or the root itself. Spaces usually have a single home
+// page, so its children are where the content divides.
+func (t *pageTree) section(id string) string {
+	node, ok := t.nodes[id]
+	if !ok {
+		return ""
+	}
+	for node.parent != nil && node.parent.parent != nil {
+		node = node.parent
+	}
+	return node.ID
+}
+
+// dir returns a page's directory relative to the space's pages directory
+func (t *pageTree) dir(id, title string) string {
+	node, ok := t.nodes[id]
+	if !ok || t.layout != LayoutNested {
+		return pageDirName(id, title, pageDirBytes)
+	}
+
+	// Nested pages live inside their ancestors' directories. Each title is cut
+	// short so deeper pages have room, and whatever is left of the path budget
+	// after a page's ancestors bounds its own name, so an ancestor's directory
+	// is named the same whichever descendant asks.
+	var chain []*pageNode
+	for n := node; n != nil; n = n.parent {
+		chain = append(chain, n)
+	}
+	slices.Reverse(chain)
+	dir := ""
+	for _, n := range chain {
+		remaining := pageDirBytes - len(dir)
+		if dir != "" {
+			remaining--
+		}
+		dir = filepath.Join(dir, pageDirName(n.ID, n.Title, min(remaining, len(n.ID)+1+maxNestedTitleBytes)))
+	}
+	return dir
+}
+
+// childLinks returns links from a page's content.md to its descendants.
+// The result is non-nil for known pages so the children macro can be expanded.
+func (t *pageTree) childLinks(pageID string) []markdown.PageLink {
+	node, ok := t.nodes[pageID]
+	if !ok {
+		return nil
+	}
+	return t.nodeLinks(t.dir(node.ID, node.Title), node.Children)
+}
+
+func (t *pageTree) nodeLinks(from string, nodes []*pageNode) []markdown.PageLink {
+	links := make([]markdown.PageLink, 0, len(nodes))
+	for _, node := range nodes {
+		target := t.dir(node.ID, node.Title)
+		rel, err := filepath.Rel(from, target)
+		if err != nil {
+			rel = target
+		}
+		links = append(links, markdown.PageLink{
+			Title:    node.Title,
+			Path:     filepath.ToSlash(filepath.Join(rel, "content.md")),
+			Children: t.nodeLinks(from, node.Children),
+		})
+	}
+	return links
+}
@@ -0,0 +1,112 @@
+package clone
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nycmonkey/confluence-reader/pkg/client"
+)
+
+// userCache resolves account IDs to users, looking each one up once per run
+// unless the lookup fails for a reason that may not last
+type userCache struct {
+	mu      sync.Mutex
+	entries map[string]*userEntry
+}
+
+type userEntry struct {
+	mu       sync.Mutex // Held during a lookup, so concurrent callers wait for it
+	resolved bool
+	user     *client.User
+}
+
+// resolveUser returns the user for accountID, or nil if it can't be resolved.
+// Accounts that don't exist or can't be seen (e.g. deleted accounts) are
+// cached so they aren't retried; other failures are retried on the next lookup.
+func (cl *Cloner) resolveUser(ctx context.Context, accountID string) *client.User {
+	if accountID == "" {
+		return nil
+	}
+
+	cl.users.mu.Lock()
+	if cl.users.entries == nil {
+		cl.users.entries = make(map[string]*userEntry)
+	}
+	entry, ok := cl.users.entries[accountID]
+	if !ok {
+		entry = &userEntry{}
+		cl.users.entries[accountID] = entry
+	}
+	cl.users.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.resolved {
+		return entry.user
+	}
+
+	user, err := cl.client.GetUserContext(ctx, accountID)
+	if err != nil {
+		if ctx.Err() == nil {
+			cl.logf("    Warning: Failed to resolve user %s: %v\n", accountID, err)
+		}
+		entry.resolved = client.IsNotFound(err) || client.IsForbidden(err)
+		return nil
+	}
+	entry.user, entry.resolved = user, true
+	return user
+}
+
+// userMetadata describes a user in metadata.json
+func userMetadata(accountID string, user *client.User) map[string]interface{} {
+	meta := map[string]interface{}{
+		"accountId": accountID,
+	}
+	if user != nil {
+		meta["displayName"] = user.Name()
+		if user.Email != "" {
+			meta["email"] = user.Email
+		}
+	}
+	return meta
+}
+
+// userLabel formats a user for frontmatter and comments as "Name <email>"
+func userLabel(accountID string, user *client.User) string {
+	if user == nil {
+		return accountID
+	}
+	if user.Email != "" {
+		return user.Name() + " <" + user.Email + ">"
+	}
+	return user.Name()
+}
+
+// parseTime parses an API timestamp, returning the zero time if it is empty or invalid
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// contentPeople holds the resolved users behind a page or blog post
+type contentPeople struct {
+	author   *client.User
+	owner    *client.User
+	modifier *client.User
+}
+
+// resolvePeople resolves a page's or blog post's author, owner and last modifier
+func (cl *Cloner) resolvePeople(ctx context.Context, info contentInfo) contentPeople {
+	people := contentPeople{
+		author: cl.resolveUser(ctx, info.authorID),
+		owner:  cl.resolveUser(ctx, info.ownerID),
+	}
+	if info.version != nil {
+		people.modifier = cl.resolveUser(ctx, info.version.AuthorID)
+	}
+	return people
+}
@@ -0,0 +1,75 @@
+package clone
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// AllVersions can be assigned to Cloner.VersionHistory to export every version of each page and blog post
+const AllVersions = -1
+
+// saveVersionHistory writes historical versions of a page or blog post under dir/versions/<n>/.
+// Versions never change once written, so versions already on disk are skipped.
+func (cl *Cloner) saveVersionHistory(ctx context.Context, item content, dir string) error {
+	info := item.describe()
+	versions, err := item.versions(ctx, cl.client)
+	if err != nil {
+		return err
+	}
+
+	// Newest first, so "last N" keeps the most recent versions
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Number > versions[j].Number
+	})
+	if cl.VersionHistory > 0 && len(versions) > cl.VersionHistory {
+		versions = versions[:cl.VersionHistory]
+	}
+
+	for _, v := range versions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		versionDir := filepath.Join(dir, "versions", strconv.Itoa(v.Number))
+		metadataPath := filepath.Join(versionDir, "metadata.json")
+		if _, err := os.Stat(metadataPath); err == nil {
+			continue
+		}
+
+		// The current version's body is already in hand
+		body := info.body
+		if !info.hasBody || versionNumber(info.version) != v.Number {
+			if body, err = item.bodyAt(ctx, cl.client, v.Number); err != nil {
+				return err
+			}
+		}
+
+		if err := os.MkdirAll(versionDir, 0755); err != nil {
+			return fmt.Errorf("failed to create version directory: %w", err)
+		}
+		if err := writeFileAtomic(filepath.Join(versionDir, "content.html"), []byte(body)); err != nil {
+			return fmt.Errorf("failed to save version %d content: %w", v.Number, err)
+		}
+
+		// Metadata is written last so an interrupted version is fetched again next run
+		metadata := map[string]interface{}{
+			"number":    v.Number,
+			"authorId":  v.AuthorID,
+			"message":   v.Message,
+			"createdAt": v.When,
+			"minorEdit": v.MinorEdit,
+		}
+		if v.AuthorID != "" {
+			metadata["author"] = userMetadata(v.AuthorID, cl.resolveUser(ctx, v.AuthorID))
+		}
+		if err := saveJSON(metadataPath, metadata); err != nil {
+			return fmt.Errorf("failed to save version %d metadata: %w", v.Number, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Comment is a page comment to render in the Markdown comments section
+type Comment struct {
+	Author    string
+	CreatedAt string
+	// Body is the comment in Confluence storage format
+	Body string
+	// InlineSelection is the page text an inline comment is anchored to
+	InlineSelection  string
+	ResolutionStatus string
+	Replies          []Comment
+}
+
+// RenderComments renders threaded comments as a "Comments" Markdown section.
+// It returns an empty string when there are no comments.
+func (c *Converter) RenderComments(comments []Comment) (string, error) {
+	if len(comments) == 0 {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Comments\n")
+	for _, comment := range comments {
+		if err := c.renderComment(&sb, comment, 3); err != nil {
+			return "", err
+		}
+	}
+
+	return postProcess(sb.String()), nil
+}
+
+// renderComment writes one comment and its replies, nesting replies one heading level deeper
+func (c *Converter) renderComment(sb *strings.Builder, comment Comment, level int) error {
+	if level > 6 {
+		level = 6
+	}
+
+	kind := "Comment"
+	if level > 3 {
+		kind = "Reply"
+	}
+	author := comment.Author
+	if author == "" {
+		author = "unknown"
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(strings.Repeat("#", level))
+	fmt.Fprintf(sb, " %s by %s", kind, author)
+	if comment.CreatedAt != "" {
+		fmt.Fprintf(sb, " (%s)", comment.CreatedAt)
+	}
+	sb.WriteString("\n\n")
+
+	if comment.InlineSelection != "" {
+		fmt.Fprintf(sb, "> On: \"%s\"\n", strings.Join(strings.Fields(comment.InlineSelection), " "))
+		if comment.ResolutionStatus != "" {
+			fmt.Fprintf(sb, ">\n> Status: %s\n", comment.ResolutionStatus)
+		}
+		sb.WriteString("\n")
+	}
+
+	body, err := c.Convert(comment.Body)
+	if err != nil {
+		return fmt.Errorf("failed to convert comment: %w", err)
+	}
+	sb.WriteString(strings.TrimSpace(body))
+	sb.WriteString("\n")
+
+	for _, reply := range comment.Replies {
+		if err := c.renderComment(sb, reply, level+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
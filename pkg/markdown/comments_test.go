@@ -0,0 +1,62 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderComments(t *testing.T) {
+	comments := []Comment{
+		{
+			Author:    "Ada",
+			CreatedAt: "2025-03-14T09:30:00Z",
+			Body:      "<p>Looks <strong>good</strong></p>",
+			Replies: []Comment{
+				{Author: "Grace", Body: "<p>Agreed</p>"},
+			},
+		},
+		{
+			Author:           "Linus",
+			Body:             "<p>Is this still accurate?</p>",
+			InlineSelection:  "rotate keys\n every 90 days",
+			ResolutionStatus: "open",
+		},
+	}
+
+	conv := NewConverter()
+	markdown, err := conv.RenderComments(comments)
+	if err != nil {
+		t.Fatalf("RenderComments failed: %v", err)
+	}
+
+	expected := []string{
+		"## Comments",
+		"### Comment by Ada (2025-03-14T09:30:00Z)",
+		"Looks **good**",
+		"#### Reply by Grace",
+		"Agreed",
+		"### Comment by Linus",
+		`> On: "rotate keys every 90 days"`,
+		"> Status: open",
+	}
+	for _, want := range expected {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, markdown)
+		}
+	}
+
+	if strings.Index(markdown, "Reply by Grace") > strings.Index(markdown, "Comment by Linus") {
+		t.Error("Expected reply to be rendered under its parent comment")
+	}
+}
+
+func TestRenderCommentsEmpty(t *testing.T) {
+	conv := NewConverter()
+	markdown, err := conv.RenderComments(nil)
+	if err != nil {
+		t.Fatalf("RenderComments failed: %v", err)
+	}
+	if markdown != "" {
+		t.Errorf("Expected empty output for no comments, got %q", markdown)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	htmlpkg "html"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,10 +22,23 @@ type PageMetadata struct {
 	PageID    string
 	SpaceKey  string
 	Version   int
+	Status    string
 	UpdatedAt time.Time
+	CreatedAt time.Time
 	Author    string
+	Owner     string
+	Modifier  string
 	ParentID  string
 	URL       string
+	Labels    []string
+	Children  []PageLink // Child pages; nil when the page hierarchy is unknown
+}
+
+// PageLink links to another page, e.g. a child listed by the children macro
+type PageLink struct {
+	Title    string
+	Path     string // Link target; defaults to the title slug
+	Children []PageLink
 }
 
 // NewConverter creates a new converter with Confluence-specific configuration
@@ -54,6 +68,11 @@ func (c *Converter) ConvertWithMetadata(html string, meta PageMetadata) (string,
 	// Generate YAML frontmatter
 	frontmatter := generateFrontmatter(meta)
 
+	// Expand child page listings now that the hierarchy is known
+	if meta.Children != nil {
+		html = expandChildrenMacro(html, meta.Children)
+	}
+
 	// Convert HTML to markdown
 	markdown, err := c.Convert(html)
 	if err != nil {
@@ -74,13 +93,29 @@ func generateFrontmatter(meta PageMetadata) string {
 	sb.WriteString(fmt.Sprintf("space_key: \"%s\"\n", meta.SpaceKey))
 	sb.WriteString(fmt.Sprintf("version: %d\n", meta.Version))
 	
+	if meta.Status != "" {
+		sb.WriteString(fmt.Sprintf("status: \"%s\"\n", escapeYAML(meta.Status)))
+	}
+
 	if !meta.UpdatedAt.IsZero() {
 		sb.WriteString(fmt.Sprintf("last_updated: \"%s\"\n", meta.UpdatedAt.Format(time.RFC3339)))
 	}
 	
+	if !meta.CreatedAt.IsZero() {
+		sb.WriteString(fmt.Sprintf("created: \"%s\"\n", meta.CreatedAt.Format(time.RFC3339)))
+	}
+
 	if meta.Author != "" {
 		sb.WriteString(fmt.Sprintf("author: \"%s\"\n", escapeYAML(meta.Author)))
 	}
+
+	if meta.Owner != "" {
+		sb.WriteString(fmt.Sprintf("owner: \"%s\"\n", escapeYAML(meta.Owner)))
+	}
+
+	if meta.Modifier != "" {
+		sb.WriteString(fmt.Sprintf("last_modified_by: \"%s\"\n", escapeYAML(meta.Modifier)))
+	}
 	
 	if meta.ParentID != "" {
 		sb.WriteString(fmt.Sprintf("parent_id: \"%s\"\n", meta.ParentID))
@@ -89,6 +124,13 @@ func generateFrontmatter(meta PageMetadata) string {
 	if meta.URL != "" {
 		sb.WriteString(fmt.Sprintf("url: \"%s\"\n", meta.URL))
 	}
+
+	if len(meta.Labels) > 0 {
+		sb.WriteString("labels:\n")
+		for _, label := range meta.Labels {
+			sb.WriteString(fmt.Sprintf("  - \"%s\"\n", escapeYAML(label)))
+		}
+	}
 	
 	sb.WriteString("---")
 	return sb.String()
@@ -116,7 +158,7 @@ func preProcess(html string) string {
 	// Convert Confluence internal links to standard links
 	html = convertInternalLinks(html)
 
-	// Remove child pages macro (TODO: needs page hierarchy context)
+	// Remove child pages macros that weren't expanded with hierarchy context
 	html = removeChildrenMacro(html)
 
 	return html
@@ -279,13 +321,61 @@ func convertInternalLinks(html string) string {
 	return html
 }
 
+// childrenMacroRe matches the children macro in both its self-closing and parameterised forms
+var childrenMacroRe = regexp.MustCompile(`(?s)<ac:structured-macro\s+ac:name="children"(?:[^>]*/>|[^>]*>(.*?)</ac:structured-macro>)`)
+
+// childrenParamRe matches the children macro parameters that control how many levels are listed
+var childrenParamRe = regexp.MustCompile(`<ac:parameter\s+ac:name="(depth|all)"[^>]*>([^<]*)</ac:parameter>`)
+
 // removeChildrenMacro removes children page listing macro
 func removeChildrenMacro(html string) string {
-	re := regexp.MustCompile(`<ac:structured-macro\s+ac:name="children"[^>]*/>`)
-	html = re.ReplaceAllString(html, `<!-- Child pages: (requires hierarchy context) -->`)
+	html = childrenMacroRe.ReplaceAllString(html, `<!-- Child pages: (requires hierarchy context) -->`)
 	return html
 }
 
+// expandChildrenMacro replaces children macros with a list of links to the child pages.
+// The macro's depth and all parameters control how many levels are listed.
+func expandChildrenMacro(html string, children []PageLink) string {
+	return childrenMacroRe.ReplaceAllStringFunc(html, func(match string) string {
+		depth := 1
+		for _, param := range childrenParamRe.FindAllStringSubmatch(match, -1) {
+			value := strings.TrimSpace(param[2])
+			switch param[1] {
+			case "depth":
+				if n, err := strconv.Atoi(value); err == nil && n > 0 {
+					depth = n
+				}
+			case "all":
+				if value == "true" {
+					depth = -1
+				}
+			}
+		}
+		return renderPageLinks(children, depth)
+	})
+}
+
+// renderPageLinks renders links as a nested HTML list, descending depth levels (-1 for all)
+func renderPageLinks(links []PageLink, depth int) string {
+	if len(links) == 0 || depth == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<ul>")
+	for _, link := range links {
+		href := link.Path
+		if href == "" {
+			href = titleToSlug(link.Title) + ".md"
+		}
+		sb.WriteString(fmt.Sprintf(`<li><a href="%s">%s</a>`, htmlpkg.EscapeString(href), htmlpkg.EscapeString(link.Title)))
+		sb.WriteString(renderPageLinks(link.Children, depth-1))
+		sb.WriteString("</li>")
+	}
+	sb.WriteString("</ul>")
+	return sb.String()
+}
+
 // postProcess cleans up the generated Markdown
 func postProcess(markdown string) string {
 	// Normalize excessive blank lines (max 2 consecutive)
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestBasicConversion(t *testing.T) {
@@ -135,6 +136,43 @@ func TestInternalLink(t *testing.T) {
 	t.Logf("Result:\n%s", markdown)
 }
 
+func TestChildrenMacro(t *testing.T) {
+	html := `<p>Sections:</p><ac:structured-macro ac:name="children" ac:schema-version="2"><ac:parameter ac:name="depth">2</ac:parameter></ac:structured-macro>`
+	children := []PageLink{
+		{Title: "Install", Path: "../1_Install/content.md", Children: []PageLink{
+			{Title: "Linux", Path: "../2_Linux/content.md", Children: []PageLink{
+				{Title: "Too Deep"},
+			}},
+		}},
+		{Title: "Upgrade Notes"},
+	}
+
+	conv := NewConverter()
+
+	// Without hierarchy context the macro is replaced by a placeholder
+	markdown, err := conv.Convert(html)
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	if contains(markdown, "ac:parameter") || contains(markdown, "Install") {
+		t.Errorf("Expected children macro to be removed, got:\n%s", markdown)
+	}
+
+	// With hierarchy context the macro lists children down to its depth
+	markdown, err = conv.ConvertWithMetadata(html, PageMetadata{Title: "Guide", Children: children})
+	if err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	for _, want := range []string{"[Install](../1_Install/content.md)", "[Linux](../2_Linux/content.md)", "[Upgrade Notes](upgrade-notes.md)"} {
+		if !contains(markdown, want) {
+			t.Errorf("Expected %q in children listing, got:\n%s", want, markdown)
+		}
+	}
+	if contains(markdown, "Too Deep") {
+		t.Errorf("Expected listing to stop at depth 2, got:\n%s", markdown)
+	}
+}
+
 func TestConvertWithMetadata(t *testing.T) {
 	html := `<h1>Test Page</h1>
 <p>This is a test page with content.</p>`
@@ -209,6 +247,58 @@ func TestFrontmatterYAMLEscaping(t *testing.T) {
 	t.Logf("Result:\n%s", markdown)
 }
 
+func TestFrontmatterLabels(t *testing.T) {
+	meta := PageMetadata{
+		Title:    "Runbook",
+		PageID:   "123",
+		SpaceKey: "OPS",
+		Version:  1,
+		Labels:   []string{"runbook", "deprecated"},
+	}
+
+	frontmatter := generateFrontmatter(meta)
+
+	if !contains(frontmatter, "labels:\n  - \"runbook\"\n  - \"deprecated\"\n") {
+		t.Errorf("Expected labels YAML list in frontmatter, got:\n%s", frontmatter)
+	}
+
+	meta.Labels = nil
+	if contains(generateFrontmatter(meta), "labels:") {
+		t.Error("Expected no labels key when page has no labels")
+	}
+}
+
+func TestFrontmatterPeopleAndDates(t *testing.T) {
+	meta := PageMetadata{
+		Title:     "Policy",
+		PageID:    "123",
+		SpaceKey:  "SEC",
+		Version:   4,
+		Status:    "archived",
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		UpdatedAt: time.Date(2025, 6, 7, 8, 9, 10, 0, time.UTC),
+		Author:    "Ada Lovelace <ada@example.com>",
+		Owner:     "Grace Hopper",
+		Modifier:  "Alan Turing",
+	}
+
+	frontmatter := generateFrontmatter(meta)
+
+	expected := []string{
+		`status: "archived"`,
+		`last_updated: "2025-06-07T08:09:10Z"`,
+		`created: "2024-01-02T03:04:05Z"`,
+		`author: "Ada Lovelace <ada@example.com>"`,
+		`owner: "Grace Hopper"`,
+		`last_modified_by: "Alan Turing"`,
+	}
+	for _, want := range expected {
+		if !contains(frontmatter, want) {
+			t.Errorf("Expected frontmatter to contain %q, got:\n%s", want, frontmatter)
+		}
+	}
+}
+
 func TestEmoticonConversion(t *testing.T) {
 	tests := []struct {
 		name     string